@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"errors"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Purposes of single-use action tokens
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+	PurposeMFAChallenge  = "mfa_challenge"
+	PurposeChangeEmail   = "change_email"
+)
+
+var ErrInvalidActionToken = errors.New("invalid, expired or already used token")
+
+// GenerateActionToken issues a signed token that authorizes a single action
+// for the user. The token ID is recorded so ConsumeActionToken accepts it once.
+func GenerateActionToken(db database.Database, userID uint, purpose string, ttl time.Duration) (string, error) {
+	id, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	record := &models.ActionToken{
+		ID:        id,
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := db.Create(record).Error; err != nil {
+		return "", err
+	}
+
+	return signToken(&Claims{
+		UserID:  userID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			ExpiresAt: jwt.NewNumericDate(record.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+}
+
+// PeekActionToken checks the token like ConsumeActionToken but leaves it
+// unused, for flows that only consume it once a second factor succeeded
+func PeekActionToken(db database.Database, tokenString, purpose string) (uint, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil || claims.Purpose != purpose || claims.ID == "" {
+		return 0, ErrInvalidActionToken
+	}
+
+	var record models.ActionToken
+	err = db.Where("id = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", claims.ID, claims.UserID, purpose, time.Now()).First(&record).Error
+	if err != nil {
+		return 0, ErrInvalidActionToken
+	}
+
+	return claims.UserID, nil
+}
+
+// ConsumeActionToken checks the token signature, purpose and expiry, marks it
+// as used and returns the ID of the user it was issued for
+func ConsumeActionToken(db database.Database, tokenString, purpose string) (uint, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil || claims.Purpose != purpose || claims.ID == "" {
+		return 0, ErrInvalidActionToken
+	}
+
+	now := time.Now()
+	result := db.Where("id = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", claims.ID, claims.UserID, purpose, now).
+		Model(&models.ActionToken{}).
+		Update("used_at", now)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrInvalidActionToken
+	}
+
+	return claims.UserID, nil
+}
+
+// RevokeActionTokens marks the unused tokens of the user for purpose as used,
+// e.g. when a newer token supersedes them
+func RevokeActionTokens(db database.Database, userID uint, purpose string) error {
+	return db.Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Model(&models.ActionToken{}).
+		Update("used_at", time.Now()).Error
+}
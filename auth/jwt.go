@@ -2,40 +2,96 @@ package auth
 
 import (
 	"errors"
+	"just-do-it-api/config"
 	"just-do-it-api/models"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtKey = []byte("your-secret-key") // TODO: Move to environment variable
+// AccessTokenTTL is how long an access token stays valid. Clients renew it with a refresh token.
+var AccessTokenTTL = config.Duration("ACCESS_TOKEN_TTL", 15*time.Minute)
+
+// Issuer and Audience are set on every token and checked when validating it
+var (
+	Issuer   = config.String("JWT_ISSUER", "just-do-it-api")
+	Audience = config.String("JWT_AUDIENCE", "just-do-it-api")
+)
 
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID    uint   `json:"user_id"`
+	SessionID string `json:"sid,omitempty"`
+	Role      string `json:"role,omitempty"`
+	// Purpose is empty for access tokens and names the action for single-use tokens
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(user *models.User) (string, error) {
+func GenerateToken(user *models.User, sessionID string) (string, error) {
 	claims := &Claims{
-		UserID: user.ID,
+		UserID:    user.ID,
+		SessionID: sessionID,
+		Role:      user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	return signToken(claims)
+}
+
+// signToken signs the claims with the active key and sets issuer and audience
+func signToken(claims *Claims) (string, error) {
+	key := Keys().Active()
+
+	claims.Issuer = Issuer
+	claims.Audience = jwt.ClaimStrings{Audience}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
 }
 
 func ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != "" {
+		return nil, errors.New("not an access token")
+	}
+
+	if claims.SessionID == "" {
+		return nil, errors.New("token is not bound to a session")
+	}
+
+	return claims, nil
+}
+
+func parseToken(tokenString string) (*Claims, error) {
+	ks := Keys()
+
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.Lookup(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+
+		// The algorithm must match the key, never trust the header alone
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return jwtKey, nil
-	})
+		return key.Public, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithAudience(Audience),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		return nil, err
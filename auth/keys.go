@@ -0,0 +1,300 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"just-do-it-api/config"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is a key that tokens are signed or verified with. Retired keys
+// only have a public half and are kept to verify tokens issued before a
+// rotation.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeySet holds every key that is accepted for verification and the one that signs new tokens
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// NewKeySet builds a key set that signs with the key activeID
+func NewKeySet(activeID string, keys ...*SigningKey) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*SigningKey, len(keys))}
+	for _, key := range keys {
+		if _, exists := ks.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	active, ok := ks.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found", activeID)
+	}
+	if active.Private == nil {
+		return nil, fmt.Errorf("active key %q has no private key", activeID)
+	}
+	ks.active = active
+
+	return ks, nil
+}
+
+// Active returns the key new tokens are signed with
+func (ks *KeySet) Active() *SigningKey {
+	return ks.active
+}
+
+// Lookup returns the key with the given kid
+func (ks *KeySet) Lookup(kid string) (*SigningKey, bool) {
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// NewSigningKey wraps a private or public RSA or Ed25519 key
+func NewSigningKey(id string, key interface{}) (*SigningKey, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("key %q: RSA keys must have at least 2048 bits", id)
+		}
+		return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, Private: k, Public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, Public: k}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Private: k, Public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Public: k}, nil
+	default:
+		return nil, fmt.Errorf("key %q: unsupported key type %T, use RSA or Ed25519", id, key)
+	}
+}
+
+// ParseSigningKeyPEM parses a PEM encoded private key (PKCS#8 or PKCS#1) or public key (PKIX or PKCS#1)
+func ParseSigningKeyPEM(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM data found", id)
+	}
+
+	var (
+		key interface{}
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %q: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %v", id, err)
+	}
+
+	return NewSigningKey(id, key)
+}
+
+// LoadKeySetFromEnv loads the signing keys configured in the environment:
+//
+//   - JWT_KEYS_DIR: a directory of PEM files. "<kid>.pem" holds a private
+//     key, "<kid>.pub.pem" the public key of a retired key.
+//   - JWT_PRIVATE_KEY and JWT_KEY_ID: a single PEM encoded private key.
+//   - JWT_ACTIVE_KEY_ID: the key that signs new tokens. It can be omitted when
+//     only one private key is configured.
+//
+// Without any configuration an ephemeral Ed25519 key is generated, so tokens
+// don't survive a restart. That is only meant for development.
+func LoadKeySetFromEnv() (*KeySet, error) {
+	var keys []*SigningKey
+
+	if dir := config.String("JWT_KEYS_DIR", ""); dir != "" {
+		dirKeys, err := loadKeysDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, dirKeys...)
+	}
+
+	if pemData := config.String("JWT_PRIVATE_KEY", ""); pemData != "" {
+		key, err := ParseSigningKeyPEM(config.String("JWT_KEY_ID", "default"), []byte(pemData))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		log.Println("No JWT signing keys configured, generating an ephemeral Ed25519 key")
+		return ephemeralKeySet()
+	}
+
+	activeID := config.String("JWT_ACTIVE_KEY_ID", "")
+	if activeID == "" {
+		var private []string
+		for _, key := range keys {
+			if key.Private != nil {
+				private = append(private, key.ID)
+			}
+		}
+		if len(private) != 1 {
+			return nil, errors.New("JWT_ACTIVE_KEY_ID is required when more than one private key is configured")
+		}
+		activeID = private[0]
+	}
+
+	return NewKeySet(activeID, keys...)
+}
+
+func loadKeysDir(dir string) ([]*SigningKey, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	keys := make([]*SigningKey, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read key file: %v", err)
+		}
+
+		id := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".pem"), ".pub")
+		key, err := ParseSigningKeyPEM(id, data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func ephemeralKeySet() (*KeySet, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomToken(8)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := NewSigningKey(id, private)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySet(key.ID, key)
+}
+
+var (
+	keySet   *KeySet
+	keySetMu sync.Mutex
+)
+
+// LoadKeys loads the signing keys from the environment. It is called on startup so misconfigured keys fail fast.
+func LoadKeys() error {
+	ks, err := LoadKeySetFromEnv()
+	if err != nil {
+		return err
+	}
+	SetKeySet(ks)
+	return nil
+}
+
+// SetKeySet replaces the keys used to sign and verify tokens
+func SetKeySet(ks *KeySet) {
+	keySetMu.Lock()
+	defer keySetMu.Unlock()
+	keySet = ks
+}
+
+// Keys returns the current key set, generating an ephemeral one if none was loaded
+func Keys() *KeySet {
+	keySetMu.Lock()
+	defer keySetMu.Unlock()
+
+	if keySet == nil {
+		ks, err := ephemeralKeySet()
+		if err != nil {
+			panic(fmt.Sprintf("failed to generate signing key: %v", err))
+		}
+		keySet = ks
+	}
+	return keySet
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set so other services can verify tokens
+func (ks *KeySet) JWKS() JWKSet {
+	ids := make([]string, 0, len(ks.keys))
+	for id := range ks.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JWKSet{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		key := ks.keys[id]
+		jwk := JWK{Use: "sig", Kid: key.ID, Alg: key.Method.Alg()}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"just-do-it-api/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// keysDir writes an RSA key "old" and an Ed25519 key "new" to a temporary directory
+func keysDir(t *testing.T) string {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+	writePEM(t, filepath.Join(dir, "old.pem"), "PRIVATE KEY", der)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ = x509.MarshalPKCS8PrivateKey(edKey)
+	writePEM(t, filepath.Join(dir, "new.pem"), "PRIVATE KEY", der)
+
+	return dir
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := keysDir(t)
+	t.Setenv("JWT_KEYS_DIR", dir)
+	defer SetKeySet(nil)
+
+	// Tokens issued with the old key...
+	t.Setenv("JWT_ACTIVE_KEY_ID", "old")
+	if err := LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := GenerateToken(&models.User{ID: 1}, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ...stay valid after switching to the new one
+	t.Setenv("JWT_ACTIVE_KEY_ID", "new")
+	if err := LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := GenerateToken(&models.User{ID: 1}, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		claims, err := ValidateToken(token)
+		if err != nil {
+			t.Errorf("expected %s token to be valid: %v", name, err)
+			continue
+		}
+		if claims.UserID != 1 {
+			t.Errorf("expected user 1 in %s token, got %d", name, claims.UserID)
+		}
+	}
+
+	parsed, _, _ := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	if parsed.Header["kid"] != "new" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("expected EdDSA token with kid new, got %v %v", parsed.Header["kid"], parsed.Method.Alg())
+	}
+
+	jwks := Keys().JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys in JWKS, got %d", len(jwks.Keys))
+	}
+	for _, key := range jwks.Keys {
+		switch key.Kid {
+		case "old":
+			if key.Kty != "RSA" || key.Alg != "RS256" || key.N == "" || key.E == "" {
+				t.Errorf("unexpected RSA JWK %+v", key)
+			}
+		case "new":
+			if key.Kty != "OKP" || key.Crv != "Ed25519" || key.X == "" {
+				t.Errorf("unexpected Ed25519 JWK %+v", key)
+			}
+		default:
+			t.Errorf("unexpected key %q", key.Kid)
+		}
+	}
+
+	// Once the old key is removed, its tokens are rejected
+	os.Remove(filepath.Join(dir, "old.pem"))
+	if err := LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(oldToken); err == nil {
+		t.Error("expected token signed with removed key to be rejected")
+	}
+}
+
+func TestValidateTokenClaims(t *testing.T) {
+	defer SetKeySet(nil)
+	SetKeySet(nil)
+
+	key := Keys().Active()
+	sign := func(claims *Claims) string {
+		token := jwt.NewWithClaims(key.Method, claims)
+		token.Header["kid"] = key.ID
+		signed, err := token.SignedString(key.Private)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	registered := func(issuer, audience string) jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{
+			name:  "Valid",
+			token: sign(&Claims{UserID: 1, SessionID: "s", RegisteredClaims: registered(Issuer, Audience)}),
+			valid: true,
+		},
+		{
+			name:  "Wrong Issuer",
+			token: sign(&Claims{UserID: 1, SessionID: "s", RegisteredClaims: registered("someone-else", Audience)}),
+		},
+		{
+			name:  "Wrong Audience",
+			token: sign(&Claims{UserID: 1, SessionID: "s", RegisteredClaims: registered(Issuer, "other-service")}),
+		},
+		{
+			name:  "Action Token",
+			token: sign(&Claims{UserID: 1, Purpose: PurposeResetPassword, RegisteredClaims: registered(Issuer, Audience)}),
+		},
+		{
+			name: "HMAC Token",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 1, SessionID: "s", RegisteredClaims: registered(Issuer, Audience)})
+				token.Header["kid"] = key.ID
+				signed, _ := token.SignedString([]byte("your-secret-key"))
+				return signed
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateToken(tt.token)
+			if tt.valid && err != nil {
+				t.Errorf("expected token to be valid: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected token to be rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"errors"
+	"just-do-it-api/config"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LockoutPolicy configures how failed logins are throttled
+type LockoutPolicy struct {
+	// FreeAttempts is how many failures per account are allowed before delays start
+	FreeAttempts int
+	// BaseDelay is the first delay; it doubles with every further failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutThreshold failures lock the account for LockoutDuration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// IPThreshold failures from one address block it for IPBlockDuration
+	IPThreshold     int
+	IPBlockDuration time.Duration
+	// FailureWindow is how long a failure counts, a quiet period resets the counter
+	FailureWindow time.Duration
+}
+
+// DefaultLockoutPolicy returns the policy configured in the environment
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		FreeAttempts:     config.Int("LOGIN_FREE_ATTEMPTS", 3),
+		BaseDelay:        config.Duration("LOGIN_BASE_DELAY", time.Second),
+		MaxDelay:         config.Duration("LOGIN_MAX_DELAY", time.Minute),
+		LockoutThreshold: config.Int("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LockoutDuration:  config.Duration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		IPThreshold:      config.Int("LOGIN_IP_THRESHOLD", 50),
+		IPBlockDuration:  config.Duration("LOGIN_IP_BLOCK_DURATION", 15*time.Minute),
+		FailureWindow:    config.Duration("LOGIN_FAILURE_WINDOW", time.Hour),
+	}
+}
+
+// AttemptStore persists failed login counters
+type AttemptStore interface {
+	// Get returns the attempt stored under key, or nil if there is none
+	Get(key string) (*models.LoginAttempt, error)
+	Save(attempt *models.LoginAttempt) error
+	Delete(key string) error
+}
+
+// LoginLimiter tracks failed logins per account and per client address
+type LoginLimiter struct {
+	Store  AttemptStore
+	Policy LockoutPolicy
+	Now    func() time.Time
+}
+
+func NewLoginLimiter(store AttemptStore) *LoginLimiter {
+	return &LoginLimiter{
+		Store:  store,
+		Policy: DefaultLockoutPolicy(),
+		Now:    time.Now,
+	}
+}
+
+// LoginDecision tells whether a login attempt may go ahead
+type LoginDecision struct {
+	// AccountLocked is set while the account is locked out
+	AccountLocked bool
+	// Throttled is set when the client has to wait before trying again
+	Throttled  bool
+	RetryAfter time.Duration
+}
+
+func (d LoginDecision) Allowed() bool {
+	return !d.AccountLocked && !d.Throttled
+}
+
+// FailureResult reports whether a failure started a lockout
+type FailureResult struct {
+	AccountLocked bool
+	IPBlocked     bool
+}
+
+func accountKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check must be called before the password is verified
+func (l *LoginLimiter) Check(email, ip string) (LoginDecision, error) {
+	now := l.Now()
+
+	account, err := l.current(accountKey(email), now)
+	if err != nil {
+		return LoginDecision{}, err
+	}
+	if account != nil {
+		if account.LockedUntil != nil && now.Before(*account.LockedUntil) {
+			return LoginDecision{AccountLocked: true, RetryAfter: account.LockedUntil.Sub(now)}, nil
+		}
+		if wait := account.LastFailureAt.Add(l.delay(account.Failures)).Sub(now); wait > 0 {
+			return LoginDecision{Throttled: true, RetryAfter: wait}, nil
+		}
+	}
+
+	address, err := l.current(ipKey(ip), now)
+	if err != nil {
+		return LoginDecision{}, err
+	}
+	if address != nil && address.LockedUntil != nil && now.Before(*address.LockedUntil) {
+		return LoginDecision{Throttled: true, RetryAfter: address.LockedUntil.Sub(now)}, nil
+	}
+
+	return LoginDecision{}, nil
+}
+
+// RecordFailure counts a failed login for the account and the address
+func (l *LoginLimiter) RecordFailure(email, ip string) (FailureResult, error) {
+	var result FailureResult
+
+	accountLocked, err := l.fail(accountKey(email), l.Policy.LockoutThreshold, l.Policy.LockoutDuration)
+	if err != nil {
+		return result, err
+	}
+	result.AccountLocked = accountLocked
+
+	ipBlocked, err := l.fail(ipKey(ip), l.Policy.IPThreshold, l.Policy.IPBlockDuration)
+	if err != nil {
+		return result, err
+	}
+	result.IPBlocked = ipBlocked
+
+	return result, nil
+}
+
+// RecordSuccess clears the failures of the account. The address counter is
+// left alone so a single valid login can't hide password spraying.
+func (l *LoginLimiter) RecordSuccess(email string) error {
+	return l.Store.Delete(accountKey(email))
+}
+
+// Unlock lifts a lockout of the account, e.g. after a password reset. It reports whether the account was locked.
+func (l *LoginLimiter) Unlock(email string) (bool, error) {
+	key := accountKey(email)
+
+	attempt, err := l.Store.Get(key)
+	if err != nil {
+		return false, err
+	}
+	if attempt == nil {
+		return false, nil
+	}
+
+	wasLocked := attempt.LockedUntil != nil && l.Now().Before(*attempt.LockedUntil)
+	return wasLocked, l.Store.Delete(key)
+}
+
+// current returns the attempt for key, ignoring one whose failures are outside the window
+func (l *LoginLimiter) current(key string, now time.Time) (*models.LoginAttempt, error) {
+	attempt, err := l.Store.Get(key)
+	if err != nil || attempt == nil {
+		return nil, err
+	}
+
+	locked := attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil)
+	if !locked && now.Sub(attempt.LastFailureAt) > l.Policy.FailureWindow {
+		return nil, nil
+	}
+	if !locked && attempt.LockedUntil != nil {
+		// The lockout ran out, start counting from scratch
+		return nil, nil
+	}
+
+	return attempt, nil
+}
+
+func (l *LoginLimiter) fail(key string, threshold int, lockDuration time.Duration) (bool, error) {
+	now := l.Now()
+
+	attempt, err := l.current(key, now)
+	if err != nil {
+		return false, err
+	}
+	if attempt == nil {
+		attempt = &models.LoginAttempt{Key: key}
+	}
+
+	attempt.Failures++
+	attempt.LastFailureAt = now
+
+	newlyLocked := false
+	if threshold > 0 && attempt.Failures >= threshold && attempt.LockedUntil == nil {
+		lockedUntil := now.Add(lockDuration)
+		attempt.LockedUntil = &lockedUntil
+		newlyLocked = true
+	}
+
+	return newlyLocked, l.Store.Save(attempt)
+}
+
+// delay returns how long to wait after the given number of account failures
+func (l *LoginLimiter) delay(failures int) time.Duration {
+	if failures < l.Policy.FreeAttempts {
+		return 0
+	}
+
+	delay := l.Policy.BaseDelay
+	for i := l.Policy.FreeAttempts; i < failures; i++ {
+		delay *= 2
+		if delay >= l.Policy.MaxDelay {
+			return l.Policy.MaxDelay
+		}
+	}
+	return delay
+}
+
+// DBAttemptStore keeps login attempts in the login_attempts table
+type DBAttemptStore struct {
+	DB database.Database
+}
+
+func NewDBAttemptStore(db database.Database) *DBAttemptStore {
+	return &DBAttemptStore{DB: db}
+}
+
+func (s *DBAttemptStore) Get(key string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := s.DB.Where("key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (s *DBAttemptStore) Save(attempt *models.LoginAttempt) error {
+	return s.DB.Save(attempt).Error
+}
+
+func (s *DBAttemptStore) Delete(key string) error {
+	return s.DB.Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+// MemoryAttemptStore keeps login attempts in memory. It is meant for tests
+// and single instance deployments.
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{attempts: make(map[string]models.LoginAttempt)}
+}
+
+func (s *MemoryAttemptStore) Get(key string) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (s *MemoryAttemptStore) Save(attempt *models.LoginAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts[attempt.Key] = *attempt
+	return nil
+}
+
+func (s *MemoryAttemptStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter() (*LoginLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := &LoginLimiter{
+		Store: NewMemoryAttemptStore(),
+		Policy: LockoutPolicy{
+			FreeAttempts:     2,
+			BaseDelay:        time.Second,
+			MaxDelay:         4 * time.Second,
+			LockoutThreshold: 5,
+			LockoutDuration:  10 * time.Minute,
+			IPThreshold:      8,
+			IPBlockDuration:  time.Hour,
+			FailureWindow:    time.Hour,
+		},
+		Now: clock.Now,
+	}
+	return limiter, clock
+}
+
+func TestLoginLimiterAccountLockout(t *testing.T) {
+	limiter, clock := newTestLimiter()
+
+	// The free attempts are not delayed
+	for i := 0; i < 2; i++ {
+		if decision, _ := limiter.Check("user@example.com", "10.0.0.1"); !decision.Allowed() {
+			t.Fatalf("attempt %d: expected login to be allowed", i+1)
+		}
+		limiter.RecordFailure("user@example.com", "10.0.0.1")
+	}
+
+	// Then the delay grows with every failure: 1s, 2s, 4s
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second} {
+		decision, _ := limiter.Check("user@example.com", "10.0.0.1")
+		if !decision.Throttled || decision.RetryAfter != expected {
+			t.Fatalf("expected to be throttled for %s, got %+v", expected, decision)
+		}
+
+		clock.Advance(expected)
+		if decision, _ := limiter.Check("USER@example.com", "10.0.0.1"); !decision.Allowed() {
+			t.Fatalf("expected login to be allowed after waiting %s", expected)
+		}
+		limiter.RecordFailure("user@example.com", "10.0.0.1")
+	}
+
+	clock.Advance(4 * time.Second)
+	result, _ := limiter.RecordFailure("user@example.com", "10.0.0.1")
+	if !result.AccountLocked {
+		t.Fatal("expected the fifth failure to lock the account")
+	}
+
+	decision, _ := limiter.Check("user@example.com", "10.0.0.2")
+	if !decision.AccountLocked || decision.RetryAfter != 10*time.Minute {
+		t.Fatalf("expected account to be locked for 10m from any address, got %+v", decision)
+	}
+
+	// Other accounts are not affected
+	if decision, _ := limiter.Check("other@example.com", "10.0.0.2"); !decision.Allowed() {
+		t.Error("expected other account to be allowed")
+	}
+
+	// The lock expires and counting starts over
+	clock.Advance(10 * time.Minute)
+	if decision, _ := limiter.Check("user@example.com", "10.0.0.1"); !decision.Allowed() {
+		t.Errorf("expected lock to expire, got %+v", decision)
+	}
+	limiter.RecordFailure("user@example.com", "10.0.0.1")
+	if decision, _ := limiter.Check("user@example.com", "10.0.0.1"); !decision.Allowed() {
+		t.Errorf("expected failures to be counted from scratch, got %+v", decision)
+	}
+}
+
+func TestLoginLimiterUnlock(t *testing.T) {
+	limiter, _ := newTestLimiter()
+
+	for i := 0; i < 5; i++ {
+		limiter.RecordFailure("user@example.com", "10.0.0.1")
+	}
+
+	unlocked, err := limiter.Unlock("user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !unlocked {
+		t.Error("expected account to have been locked")
+	}
+	if decision, _ := limiter.Check("user@example.com", "10.0.0.3"); !decision.Allowed() {
+		t.Errorf("expected unlocked account to be allowed, got %+v", decision)
+	}
+}
+
+func TestLoginLimiterIPBlock(t *testing.T) {
+	limiter, clock := newTestLimiter()
+
+	// Spraying one password over many accounts blocks the address
+	var result FailureResult
+	for i := 0; i < 8; i++ {
+		result, _ = limiter.RecordFailure(string(rune('a'+i))+"@example.com", "10.0.0.1")
+	}
+	if !result.IPBlocked {
+		t.Fatal("expected address to be blocked")
+	}
+
+	decision, _ := limiter.Check("new@example.com", "10.0.0.1")
+	if !decision.Throttled || decision.AccountLocked {
+		t.Errorf("expected address to be throttled, got %+v", decision)
+	}
+	if decision, _ := limiter.Check("new@example.com", "10.0.0.2"); !decision.Allowed() {
+		t.Error("expected other address to be allowed")
+	}
+
+	clock.Advance(time.Hour)
+	if decision, _ := limiter.Check("new@example.com", "10.0.0.1"); !decision.Allowed() {
+		t.Errorf("expected block to expire, got %+v", decision)
+	}
+}
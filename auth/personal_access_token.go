@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"errors"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"strings"
+	"time"
+)
+
+// PersonalAccessTokenPrefix marks personal access tokens so they can be told apart from JWTs
+const PersonalAccessTokenPrefix = "jdi_pat_"
+
+// Scopes that can be granted to personal access tokens
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+)
+
+var ErrInvalidPersonalAccessToken = errors.New("invalid, expired or revoked personal access token")
+
+// GeneratePersonalAccessToken returns a new token and its display prefix
+func GeneratePersonalAccessToken() (token, prefix string, err error) {
+	random, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	token = PersonalAccessTokenPrefix + random
+	return token, token[:len(PersonalAccessTokenPrefix)+6], nil
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// ValidatePersonalAccessToken looks up an active token and records that it was used
+func ValidatePersonalAccessToken(db database.Database, token string) (*models.PersonalAccessToken, error) {
+	var pat models.PersonalAccessToken
+	if err := db.Where("token_hash = ? AND revoked_at IS NULL", HashToken(token)).First(&pat).Error; err != nil {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+
+	now := time.Now()
+	if pat.ExpiresAt != nil && now.After(*pat.ExpiresAt) {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+
+	if err := db.Where("id = ?", pat.ID).Model(&models.PersonalAccessToken{}).Update("last_used_at", now).Error; err != nil {
+		return nil, err
+	}
+	pat.LastUsedAt = &now
+
+	return &pat, nil
+}
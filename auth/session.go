@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"just-do-it-api/config"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"time"
+)
+
+// RefreshTokenTTL is how long a session may stay idle before it has to log in again
+var RefreshTokenTTL = config.Duration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+	ErrSessionRevoked      = errors.New("session has been revoked or has expired")
+)
+
+// CreateSession starts a new session for the user and returns it together with its first refresh token
+func CreateSession(db database.Database, userID uint, userAgent, ipAddress string) (*models.Session, string, error) {
+	id, err := randomToken(16)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL),
+	}
+	if err := db.Create(session).Error; err != nil {
+		return nil, "", err
+	}
+
+	refreshToken, err := issueRefreshToken(db, session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return session, refreshToken, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one. Presenting a
+// token that was already rotated revokes the whole session, since either the
+// client or an attacker is holding a stale copy.
+func RotateRefreshToken(db database.Database, refreshToken string) (*models.Session, string, error) {
+	var stored models.RefreshToken
+	if err := db.Where("token_hash = ?", HashToken(refreshToken)).First(&stored).Error; err != nil {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	var session models.Session
+	if err := db.Where("id = ?", stored.SessionID).First(&session).Error; err != nil {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if stored.UsedAt != nil {
+		if err := RevokeSession(db, session.ID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	if now.After(stored.ExpiresAt) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	// Mark the token as used only if nobody else did it first, so two
+	// concurrent refreshes with the same token are also treated as reuse
+	result := db.Where("id = ? AND used_at IS NULL", stored.ID).Model(&models.RefreshToken{}).Update("used_at", now)
+	if result.Error != nil {
+		return nil, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := RevokeSession(db, session.ID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(RefreshTokenTTL)
+	if err := db.Save(&session).Error; err != nil {
+		return nil, "", err
+	}
+
+	newToken, err := issueRefreshToken(db, &session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &session, newToken, nil
+}
+
+// ValidateSession checks that the session an access token was issued for is still active
+func ValidateSession(db database.Database, sessionID string) error {
+	var session models.Session
+	if err := db.Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return ErrSessionRevoked
+	}
+
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// RevokeSession ends a session. Its access tokens are rejected from now on and its refresh tokens can no longer be used.
+func RevokeSession(db database.Database, sessionID string) error {
+	return db.Where("id = ? AND revoked_at IS NULL", sessionID).Model(&models.Session{}).Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserSessions ends every session of the user except exceptSessionID, which may be empty
+func RevokeUserSessions(db database.Database, userID uint, exceptSessionID string) error {
+	return db.Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, exceptSessionID).Model(&models.Session{}).Update("revoked_at", time.Now()).Error
+}
+
+// HashToken returns the hex encoded SHA-256 of an opaque token. Only hashes are stored in the database.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func issueRefreshToken(db database.Database, session *models.Session) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	stored := &models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: HashToken(token),
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := db.Create(stored).Error; err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
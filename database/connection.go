@@ -8,7 +8,7 @@ import (
 )
 
 func initDB() *gorm.DB {
-	dsn := "host=localhost port=5432 user=postgres password=postgres dbname=just-do-it-db sslmode=disable"
+	dsn := "host=localhost port=5432 user=postgres password=postgres dbname=just-do-it-db sslmode=disable TimeZone=UTC"
 	con, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 
 	if err != nil {
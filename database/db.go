@@ -12,6 +12,8 @@ type Database interface {
 	Delete(value interface{}, conds ...interface{}) *gorm.DB
 	Where(query interface{}, args ...interface{}) *gorm.DB
 	AutoMigrate(dst ...interface{}) error
+	Unscoped() *gorm.DB
+	Transaction(fc func(tx *gorm.DB) error) error
 }
 
 type GormDB struct {
@@ -46,6 +48,14 @@ func (g *GormDB) AutoMigrate(dst ...interface{}) error {
 	return g.db.AutoMigrate(dst...)
 }
 
+func (g *GormDB) Unscoped() *gorm.DB {
+	return g.db.Unscoped()
+}
+
+func (g *GormDB) Transaction(fc func(tx *gorm.DB) error) error {
+	return g.db.Transaction(fc)
+}
+
 var db Database
 
 func CreateConnection() Database {
@@ -70,6 +70,24 @@ func ResetDatabase(dsn string) error {
 
 	// Drop all tables
 	if _, err := sqlDB.Exec(`
+		DROP TABLE IF EXISTS task_events CASCADE;
+		DROP FUNCTION IF EXISTS reject_task_event_change();
+		DROP TABLE IF EXISTS saved_queries CASCADE;
+		DROP TABLE IF EXISTS notifications CASCADE;
+		DROP TABLE IF EXISTS reminders CASCADE;
+		DROP TABLE IF EXISTS projects CASCADE;
+		DROP TABLE IF EXISTS task_tags CASCADE;
+		DROP TABLE IF EXISTS tags CASCADE;
+		DROP TABLE IF EXISTS data_exports CASCADE;
+		DROP TABLE IF EXISTS oidc_login_states CASCADE;
+		DROP TABLE IF EXISTS user_identities CASCADE;
+		DROP TABLE IF EXISTS audit_events CASCADE;
+		DROP TABLE IF EXISTS login_attempts CASCADE;
+		DROP TABLE IF EXISTS personal_access_tokens CASCADE;
+		DROP TABLE IF EXISTS recovery_codes CASCADE;
+		DROP TABLE IF EXISTS action_tokens CASCADE;
+		DROP TABLE IF EXISTS refresh_tokens CASCADE;
+		DROP TABLE IF EXISTS sessions CASCADE;
 		DROP TABLE IF EXISTS tasks CASCADE;
 		DROP TABLE IF EXISTS users CASCADE;
 		DROP TABLE IF EXISTS schema_migrations CASCADE;
@@ -2,6 +2,7 @@ package database
 
 import (
 	"just-do-it-api/models"
+	"log"
 	"time"
 
 	"gorm.io/driver/sqlite"
@@ -22,26 +23,33 @@ func NewMockDB() Database {
 		panic("failed to connect database")
 	}
 
-	// Initialize database with Task model
-	err = db.AutoMigrate(&models.Task{})
+	// Initialize database with the User, Task, Tag, Project, Reminder, Notification, SavedQuery and TaskEvent models
+	err = db.AutoMigrate(&models.User{}, &models.Task{}, &models.Tag{}, &models.Project{}, &models.Reminder{}, &models.Notification{}, &models.SavedQuery{}, &models.TaskEvent{})
 	if err != nil {
 		panic("failed to migrate database")
 	}
 
+	// FTS5 needs the sqlite_fts5 build tag, search falls back to LIKE without it
+	if err := CreateSQLiteSearchIndex(db); err != nil {
+		log.Printf("Search falls back to LIKE, SQLite has no FTS5: %v", err)
+	}
+
 	// Create initial tasks
+	tomorrow := time.Now().Add(24 * time.Hour)
+	dayAfterTomorrow := time.Now().Add(48 * time.Hour)
 	tasks := []models.Task{
 		{
 			ID:          "1",
 			Title:       "Test Task 1",
 			Description: "Description 1",
-			Deadline:    time.Now().Add(24 * time.Hour),
+			Deadline:    &tomorrow,
 			Completed:   false,
 		},
 		{
 			ID:          "2",
 			Title:       "Test Task 2",
 			Description: "Description 2",
-			Deadline:    time.Now().Add(48 * time.Hour),
+			Deadline:    &dayAfterTomorrow,
 			Completed:   true,
 		},
 	}
@@ -137,3 +145,11 @@ func (m *MockDB) Where(query interface{}, args ...interface{}) *gorm.DB {
 func (m *MockDB) AutoMigrate(dst ...interface{}) error {
 	return m.db.AutoMigrate(dst...)
 }
+
+func (m *MockDB) Unscoped() *gorm.DB {
+	return m.db.Unscoped()
+}
+
+func (m *MockDB) Transaction(fc func(tx *gorm.DB) error) error {
+	return m.db.Transaction(fc)
+}
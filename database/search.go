@@ -0,0 +1,37 @@
+package database
+
+import "gorm.io/gorm"
+
+// SearchIndexTable is the FTS5 table that indexes task titles and
+// descriptions on SQLite. Postgres uses the search_vector column of tasks.
+const SearchIndexTable = "tasks_fts"
+
+// CreateSQLiteSearchIndex creates the FTS5 index of full-text search on
+// SQLite and the triggers that keep it in sync with the tasks table. It fails
+// when SQLite is built without FTS5, which go-sqlite3 only includes with the
+// sqlite_fts5 build tag.
+func CreateSQLiteSearchIndex(db *gorm.DB) error {
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(title, description, content='tasks', tokenize='unicode61 remove_diacritics 2')`,
+		`CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks BEGIN
+			INSERT INTO tasks_fts(rowid, title, description) VALUES (new.rowid, new.title, new.description);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks BEGIN
+			INSERT INTO tasks_fts(tasks_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE ON tasks BEGIN
+			INSERT INTO tasks_fts(tasks_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+			INSERT INTO tasks_fts(rowid, title, description) VALUES (new.rowid, new.title, new.description);
+		END`,
+		`INSERT INTO tasks_fts(tasks_fts) VALUES ('rebuild')`,
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
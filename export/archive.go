@@ -0,0 +1,110 @@
+// Package export builds the archives users download to get a copy of all
+// data stored about them.
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"time"
+)
+
+const readme = `Just Do It data export
+
+Every file comes as JSON and as CSV:
+
+  profile      your account
+  tasks        all of your tasks, including deleted ones (deleted_at is set)
+  projects     the projects your tasks are organized in
+  sessions     the devices you logged in with, including revoked ones
+  audit_events security relevant events of your account
+
+Exported at %s.
+`
+
+// WriteArchive writes a zip archive with all data of the user to w
+func WriteArchive(db database.Database, userID uint, w io.Writer) error {
+	var user models.User
+	if err := db.Unscoped().First(&user, userID).Error; err != nil {
+		return fmt.Errorf("could not load user: %v", err)
+	}
+
+	var tasks []models.Task
+	if err := db.Unscoped().Where("user_id = ?", userID).Order("created_at").Preload("Tags").Find(&tasks).Error; err != nil {
+		return fmt.Errorf("could not load tasks: %v", err)
+	}
+
+	var projects []models.Project
+	if err := db.Where("user_id = ?", userID).Order("position").Find(&projects).Error; err != nil {
+		return fmt.Errorf("could not load projects: %v", err)
+	}
+
+	var sessions []models.Session
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&sessions).Error; err != nil {
+		return fmt.Errorf("could not load sessions: %v", err)
+	}
+
+	var events []models.AuditEvent
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&events).Error; err != nil {
+		return fmt.Errorf("could not load audit events: %v", err)
+	}
+
+	profile := []models.ExportedUser{models.NewExportedUser(user)}
+
+	exportedTasks := make([]models.ExportedTask, len(tasks))
+	for i, task := range tasks {
+		exportedTasks[i] = models.NewExportedTask(task)
+	}
+
+	exportedSessions := make([]models.ExportedSession, len(sessions))
+	for i, session := range sessions {
+		exportedSessions[i] = models.NewExportedSession(session)
+	}
+
+	zw := zip.NewWriter(w)
+
+	readmeFile, err := zw.Create("README.txt")
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(readmeFile, readme, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name    string
+		single  interface{}
+		records interface{}
+	}{
+		{"profile", profile[0], profile},
+		{"tasks", exportedTasks, exportedTasks},
+		{"projects", projects, projects},
+		{"sessions", exportedSessions, exportedSessions},
+		{"audit_events", events, events},
+	}
+
+	for _, file := range files {
+		jsonFile, err := zw.Create(file.name + ".json")
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(jsonFile)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.single); err != nil {
+			return fmt.Errorf("could not write %s.json: %v", file.name, err)
+		}
+
+		csvFile, err := zw.Create(file.name + ".csv")
+		if err != nil {
+			return err
+		}
+		if err := writeCSV(csvFile, file.records); err != nil {
+			return fmt.Errorf("could not write %s.csv: %v", file.name, err)
+		}
+	}
+
+	return zw.Close()
+}
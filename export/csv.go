@@ -0,0 +1,101 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// writeCSV writes a slice of structs as CSV. Columns and values follow the
+// JSON serialization of the records, so both formats contain the same fields.
+func writeCSV(w io.Writer, records interface{}) error {
+	value := reflect.ValueOf(records)
+	if value.Kind() != reflect.Slice {
+		return fmt.Errorf("expected a slice, got %T", records)
+	}
+
+	columns := jsonFieldNames(value.Type().Elem())
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	for i := 0; i < value.Len(); i++ {
+		encoded, err := json.Marshal(value.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(encoded, &fields); err != nil {
+			return err
+		}
+
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			row[j] = csvValue(fields[column])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// jsonFieldNames lists the JSON names of the fields of a struct type in
+// declaration order, including those of embedded structs
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+
+			name, _, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				walk(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	walk(t)
+
+	return names
+}
+
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
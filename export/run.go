@@ -0,0 +1,152 @@
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"just-do-it-api/config"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// Dir is where finished archives are stored
+	Dir = config.String("EXPORT_DIR", "exports")
+
+	// Retention is how long a finished archive can be downloaded
+	Retention = config.Duration("EXPORT_RETENTION", 7*24*time.Hour)
+
+	// staleAfter marks exports failed that didn't finish in time, e.g. because the server restarted
+	staleAfter = time.Hour
+)
+
+// NewID returns a random export ID
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Path returns the location of the archive of an export
+func Path(exportID string) string {
+	return filepath.Join(Dir, exportID+".zip")
+}
+
+// Run builds the archive of a pending export. Exports that are already
+// running or done are left alone, so Run can safely be called twice.
+func Run(ctx context.Context, db database.Database, exportID string) error {
+	result := db.Where("id = ? AND status = ?", exportID, models.ExportPending).
+		Model(&models.DataExport{}).
+		Update("status", models.ExportRunning)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	var dataExport models.DataExport
+	if err := db.First(&dataExport, "id = ?", exportID).Error; err != nil {
+		return err
+	}
+
+	size, err := build(ctx, db, &dataExport)
+	if err != nil {
+		db.Where("id = ?", exportID).Model(&models.DataExport{}).Updates(map[string]interface{}{
+			"status": models.ExportFailed,
+			"error":  "Failed to build the archive",
+		})
+		return fmt.Errorf("export %s failed: %v", exportID, err)
+	}
+
+	now := time.Now()
+	return db.Where("id = ?", exportID).Model(&models.DataExport{}).Updates(map[string]interface{}{
+		"status":       models.ExportCompleted,
+		"size":         size,
+		"completed_at": now,
+		"expires_at":   now.Add(Retention),
+	}).Error
+}
+
+// build writes the archive to a temporary file first, so a download never sees a partial archive
+func build(ctx context.Context, db database.Database, dataExport *models.DataExport) (int64, error) {
+	if err := os.MkdirAll(Dir, 0o700); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(Dir, dataExport.ID+"-*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := WriteArchive(db, dataExport.UserID, tmp); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), Path(dataExport.ID)); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// PurgeExpired deletes expired exports and archives, gives up on exports that
+// never finished, and removes files left behind by deleted accounts
+func PurgeExpired(ctx context.Context, db database.Database, now time.Time) error {
+	var expired []models.DataExport
+	if err := db.Where("expires_at <= ?", now).Find(&expired).Error; err != nil {
+		return err
+	}
+	for _, dataExport := range expired {
+		if err := os.Remove(Path(dataExport.ID)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := db.Delete(&dataExport).Error; err != nil {
+			return err
+		}
+	}
+
+	err := db.Where("status IN ? AND created_at <= ?", []string{models.ExportPending, models.ExportRunning}, now.Add(-staleAfter)).
+		Model(&models.DataExport{}).
+		Updates(map[string]interface{}{"status": models.ExportFailed, "error": "Export did not finish in time"}).Error
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(Dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || info.ModTime().After(now.Add(-Retention)) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(Dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove old export file %s: %v", entry.Name(), err)
+		}
+	}
+	return nil
+}
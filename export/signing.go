@@ -0,0 +1,47 @@
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"just-do-it-api/config"
+	"log"
+	"strconv"
+	"time"
+)
+
+// secret signs download URLs. Without EXPORT_URL_SECRET a random secret is
+// used, so URLs stop working after a restart and differ between instances.
+var secret = loadSecret()
+
+func loadSecret() []byte {
+	if s := config.String("EXPORT_URL_SECRET", ""); s != "" {
+		return []byte(s)
+	}
+
+	log.Printf("EXPORT_URL_SECRET is not set, using a random secret for export download URLs")
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Sign returns the signature of a download URL for the export that is valid until expires
+func Sign(exportID string, expires time.Time) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(exportID + "\n" + strconv.FormatInt(expires.Unix(), 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and expiry (Unix seconds) of a download URL
+func Verify(exportID, expires, signature string, now time.Time) bool {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > unix {
+		return false
+	}
+
+	expected := Sign(exportID, time.Unix(unix, 0))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
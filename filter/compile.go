@@ -0,0 +1,329 @@
+package filter
+
+import (
+	"just-do-it-api/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Env is what an expression is compiled for
+type Env struct {
+	UserID   uint           // tags and projects are looked up among the user's
+	Location *time.Location // dates are days in this time zone
+	Now      time.Time      // for today, tomorrow and yesterday
+}
+
+type fieldCompiler func(c *compiler, cmp *Comparison) (string, error)
+
+var fields = map[string]fieldCompiler{
+	"completed":   boolean("completed"),
+	"recurring":   recurring,
+	"priority":    priority,
+	"deadline":    deadline,
+	"start":       date("start_date"),
+	"created":     timestamp("created_at"),
+	"title":       text("title"),
+	"description": text("description"),
+	"tag":         tag,
+	"project":     project,
+}
+
+// Fields returns the names of the fields expressions can compare
+func Fields() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compile turns an expression into a condition on the tasks table. Values
+// only ever become arguments for the ? placeholders of the condition.
+func Compile(expr Expr, env Env) (string, []interface{}, error) {
+	if env.Location == nil {
+		env.Location = time.UTC
+	}
+	if env.Now.IsZero() {
+		env.Now = time.Now()
+	}
+
+	c := &compiler{env: env}
+	sql, err := c.compile(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, c.args, nil
+}
+
+type compiler struct {
+	env  Env
+	args []interface{}
+}
+
+// arg adds an argument and returns its placeholder
+func (c *compiler) arg(value interface{}) string {
+	c.args = append(c.args, value)
+	return "?"
+}
+
+func (c *compiler) compile(expr Expr) (string, error) {
+	switch e := expr.(type) {
+	case *And:
+		return c.binary(e.Left, "AND", e.Right)
+	case *Or:
+		return c.binary(e.Left, "OR", e.Right)
+	case *Not:
+		x, err := c.compile(e.X)
+		if err != nil {
+			return "", err
+		}
+		// Comparisons with NULL are unknown, and so would be their negation
+		return "NOT COALESCE(" + x + ", FALSE)", nil
+	case *Comparison:
+		compile, ok := fields[e.Field]
+		if !ok {
+			return "", errorf(e.Pos, "unknown field %s, use %s", e.Field, strings.Join(Fields(), ", "))
+		}
+		return compile(c, e)
+	default:
+		panic("filter: unknown expression")
+	}
+}
+
+func (c *compiler) binary(left Expr, op string, right Expr) (string, error) {
+	l, err := c.compile(left)
+	if err != nil {
+		return "", err
+	}
+	r, err := c.compile(right)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+// only fails comparisons with operators the field doesn't support
+func only(cmp *Comparison, ops ...string) error {
+	for _, op := range ops {
+		if cmp.Op == op {
+			return nil
+		}
+	}
+	return errorf(cmp.Pos+len(cmp.Field), "%s can't be compared with %s, use %s", cmp.Field, cmp.Op, strings.Join(ops, " "))
+}
+
+var equality = []string{OpMatch, OpEqual, OpNotEqual}
+
+// sqlOp translates an operator for columns that compare as a whole
+func sqlOp(op string) string {
+	switch op {
+	case OpMatch, OpEqual:
+		return "="
+	case OpNotEqual:
+		return "<>"
+	default:
+		return op
+	}
+}
+
+func isNone(value string) bool {
+	return strings.EqualFold(value, "none")
+}
+
+func boolean(col string) fieldCompiler {
+	return func(c *compiler, cmp *Comparison) (string, error) {
+		if err := only(cmp, equality...); err != nil {
+			return "", err
+		}
+		value, err := strconv.ParseBool(cmp.Value)
+		if err != nil {
+			return "", errorf(cmp.ValuePos, "%s must be true or false", cmp.Field)
+		}
+		return col + " " + sqlOp(cmp.Op) + " " + c.arg(value), nil
+	}
+}
+
+func recurring(c *compiler, cmp *Comparison) (string, error) {
+	if err := only(cmp, equality...); err != nil {
+		return "", err
+	}
+	value, err := strconv.ParseBool(cmp.Value)
+	if err != nil {
+		return "", errorf(cmp.ValuePos, "recurring must be true or false")
+	}
+	if value == (cmp.Op == OpNotEqual) {
+		return "(recurrence IS NULL OR recurrence = '')", nil
+	}
+	return "(recurrence IS NOT NULL AND recurrence <> '')", nil
+}
+
+func priority(c *compiler, cmp *Comparison) (string, error) {
+	value, err := models.ParsePriority(strings.ToLower(cmp.Value))
+	if err != nil {
+		n, convErr := strconv.Atoi(cmp.Value)
+		if convErr != nil || n < int(models.PriorityNone) || n > int(models.PriorityUrgent) {
+			return "", errorf(cmp.ValuePos, "priority must be none, low, medium, high or urgent")
+		}
+		value = models.Priority(n)
+	}
+	return "priority " + sqlOp(cmp.Op) + " " + c.arg(int(value)), nil
+}
+
+// day parses a date such as 2026-11-01, today, tomorrow or yesterday into
+// midnight of that day
+func (c *compiler) day(value string) (time.Time, bool) {
+	now := c.env.Now.In(c.env.Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, c.env.Location)
+	switch strings.ToLower(value) {
+	case "today":
+		return today, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	}
+
+	day, err := time.ParseInLocation(models.DateLayout, value, c.env.Location)
+	return day, err == nil
+}
+
+// dayRange compares a timestamp column with the day from start to end.
+// Times are passed in UTC so that they compare correctly however the
+// database stores them.
+func (c *compiler) dayRange(col, op string, start time.Time) string {
+	end := start.AddDate(0, 0, 1)
+	switch op {
+	case OpMatch, OpEqual:
+		return "(" + col + " >= " + c.arg(start.UTC()) + " AND " + col + " < " + c.arg(end.UTC()) + ")"
+	case OpNotEqual:
+		return "(" + col + " < " + c.arg(start.UTC()) + " OR " + col + " >= " + c.arg(end.UTC()) + ")"
+	case OpLess:
+		return col + " < " + c.arg(start.UTC())
+	case OpLessEqual:
+		return col + " < " + c.arg(end.UTC())
+	case OpMore:
+		return col + " >= " + c.arg(end.UTC())
+	default:
+		return col + " >= " + c.arg(start.UTC())
+	}
+}
+
+// deadline matches timed deadlines and the due dates of all-day tasks.
+// Timestamps only match timed deadlines; none matches undated tasks.
+func deadline(c *compiler, cmp *Comparison) (string, error) {
+	if isNone(cmp.Value) {
+		if err := only(cmp, equality...); err != nil {
+			return "", err
+		}
+		if cmp.Op == OpNotEqual {
+			return "(deadline IS NOT NULL OR due_date IS NOT NULL)", nil
+		}
+		return "(deadline IS NULL AND due_date IS NULL)", nil
+	}
+
+	if day, ok := c.day(cmp.Value); ok {
+		timed := c.dayRange("deadline", cmp.Op, day)
+		return "(" + timed + " OR due_date " + sqlOp(cmp.Op) + " " + c.arg(models.DateOf(day)) + ")", nil
+	}
+	if t, err := time.Parse(time.RFC3339, cmp.Value); err == nil {
+		return "deadline " + sqlOp(cmp.Op) + " " + c.arg(t.UTC()), nil
+	}
+	return "", errorf(cmp.ValuePos, "deadline must be a date such as 2026-11-01, today, a time such as 2026-11-01T09:00:00Z or none")
+}
+
+func date(col string) fieldCompiler {
+	return func(c *compiler, cmp *Comparison) (string, error) {
+		if isNone(cmp.Value) {
+			if err := only(cmp, equality...); err != nil {
+				return "", err
+			}
+			if cmp.Op == OpNotEqual {
+				return col + " IS NOT NULL", nil
+			}
+			return col + " IS NULL", nil
+		}
+
+		day, ok := c.day(cmp.Value)
+		if !ok {
+			return "", errorf(cmp.ValuePos, "%s must be a date such as 2026-11-01, today or none", cmp.Field)
+		}
+		return col + " " + sqlOp(cmp.Op) + " " + c.arg(models.DateOf(day)), nil
+	}
+}
+
+func timestamp(col string) fieldCompiler {
+	return func(c *compiler, cmp *Comparison) (string, error) {
+		if day, ok := c.day(cmp.Value); ok {
+			return c.dayRange(col, cmp.Op, day), nil
+		}
+		if t, err := time.Parse(time.RFC3339, cmp.Value); err == nil {
+			return col + " " + sqlOp(cmp.Op) + " " + c.arg(t.UTC()), nil
+		}
+		return "", errorf(cmp.ValuePos, "%s must be a date such as 2026-11-01, today or a time such as 2026-11-01T09:00:00Z", cmp.Field)
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// text matches part of the column with ":", case-insensitively, and the whole column with = and !=
+func text(col string) fieldCompiler {
+	return func(c *compiler, cmp *Comparison) (string, error) {
+		if err := only(cmp, equality...); err != nil {
+			return "", err
+		}
+		if cmp.Op == OpMatch {
+			pattern := "%" + likeEscaper.Replace(strings.ToLower(cmp.Value)) + "%"
+			return "LOWER(" + col + ") LIKE " + c.arg(pattern) + ` ESCAPE '\'`, nil
+		}
+		return col + " " + sqlOp(cmp.Op) + " " + c.arg(cmp.Value), nil
+	}
+}
+
+func tag(c *compiler, cmp *Comparison) (string, error) {
+	if err := only(cmp, equality...); err != nil {
+		return "", err
+	}
+
+	if isNone(cmp.Value) {
+		if cmp.Op == OpNotEqual {
+			return "id IN (SELECT task_id FROM task_tags)", nil
+		}
+		return "id NOT IN (SELECT task_id FROM task_tags)", nil
+	}
+
+	in := "IN"
+	if cmp.Op == OpNotEqual {
+		in = "NOT IN"
+	}
+	return "id " + in + " (SELECT task_tags.task_id FROM task_tags JOIN tags ON tags.id = task_tags.tag_id" +
+		" WHERE tags.user_id = " + c.arg(c.env.UserID) + " AND tags.name = " + c.arg(cmp.Value) + ")", nil
+}
+
+// project matches a project by ID or by name, case-insensitively
+func project(c *compiler, cmp *Comparison) (string, error) {
+	if err := only(cmp, equality...); err != nil {
+		return "", err
+	}
+
+	if isNone(cmp.Value) {
+		if cmp.Op == OpNotEqual {
+			return "project_id IS NOT NULL", nil
+		}
+		return "project_id IS NULL", nil
+	}
+
+	var match string
+	if id, err := strconv.ParseUint(cmp.Value, 10, 64); err == nil {
+		match = "project_id = " + c.arg(uint(id))
+	} else {
+		match = "project_id IN (SELECT id FROM projects WHERE user_id = " + c.arg(c.env.UserID) +
+			" AND LOWER(name) = " + c.arg(strings.ToLower(cmp.Value)) + ")"
+	}
+	if cmp.Op == OpNotEqual {
+		return "(project_id IS NULL OR NOT " + match + ")", nil
+	}
+	return match, nil
+}
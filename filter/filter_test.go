@@ -0,0 +1,175 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"just-do-it-api/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"completed:false", "completed:false"},
+		{"completed:false AND priority>=high", "(completed:false AND priority>=high)"},
+		{"completed:false priority>=high", "(completed:false AND priority>=high)"},
+		{"tag:work OR tag:home AND priority>high", "(tag:work OR (tag:home AND priority>high))"},
+		{"(tag:work OR tag:home) and NOT completed:true", "((tag:work OR tag:home) AND NOT completed:true)"},
+		{`title:"pay \"the\" rent" deadline<2026-11-01`, `(title:pay "the" rent AND deadline<2026-11-01)`},
+		{"deadline>=2026-11-01T09:00:00+01:00", "deadline>=2026-11-01T09:00:00+01:00"},
+		{"NOT(tag:work)", "NOT tag:work"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := format(expr); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"", 0},
+		{"completed", 9},
+		{"completed:", 10},
+		{"completed:false AND", 19},
+		{"(tag:work OR tag:home", 0},
+		{"tag:work)", 8},
+		{`title:"unfinished`, 6},
+		{"completed:false AND =3", 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var parseErr *Error
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a parse error, got %v", err)
+			}
+			if parseErr.Pos != tt.pos {
+				t.Errorf("expected the error at position %d, got %d: %s", tt.pos, parseErr.Pos, parseErr.Message)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	env := Env{UserID: 7, Location: berlin, Now: time.Date(2026, 10, 18, 12, 0, 0, 0, berlin)}
+	midnight := time.Date(2026, 11, 1, 0, 0, 0, 0, berlin).UTC()
+	nov1, _ := models.ParseDate("2026-11-01")
+
+	tests := []struct {
+		input string
+		sql   string
+		args  []interface{}
+	}{
+		{
+			"completed:false AND priority>=high",
+			"(completed = ? AND priority >= ?)",
+			[]interface{}{false, int(models.PriorityHigh)},
+		},
+		{
+			"deadline<2026-11-01",
+			"(deadline < ? OR due_date < ?)",
+			[]interface{}{midnight, nov1},
+		},
+		{
+			"deadline:none OR start<=today",
+			"((deadline IS NULL AND due_date IS NULL) OR start_date <= ?)",
+			[]interface{}{models.DateOf(env.Now)},
+		},
+		{
+			"NOT tag:work",
+			"NOT COALESCE(id IN (SELECT task_tags.task_id FROM task_tags JOIN tags ON tags.id = task_tags.tag_id WHERE tags.user_id = ? AND tags.name = ?), FALSE)",
+			[]interface{}{uint(7), "work"},
+		},
+		{
+			"title:50%_off",
+			`LOWER(title) LIKE ? ESCAPE '\'`,
+			[]interface{}{`%50\%\_off%`},
+		},
+		{
+			`project:"Side Project" OR project:3`,
+			"(project_id IN (SELECT id FROM projects WHERE user_id = ? AND LOWER(name) = ?) OR project_id = ?)",
+			[]interface{}{uint(7), "side project", uint(3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sql, args, err := Compile(expr, env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.sql {
+				t.Errorf("expected %s, got %s", tt.sql, sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("expected arguments %v, got %v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"color:red", 0},
+		{"completed:maybe", 10},
+		{"completed:false AND tag>work", 23},
+		{"priority>=highest", 10},
+		{"deadline<soon", 9},
+		{"start>=none", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _, err = Compile(expr, Env{})
+			var compileErr *Error
+			if !errors.As(err, &compileErr) {
+				t.Fatalf("expected an error, got %v", err)
+			}
+			if compileErr.Pos != tt.pos {
+				t.Errorf("expected the error at position %d, got %d: %s", tt.pos, compileErr.Pos, compileErr.Message)
+			}
+		})
+	}
+}
+
+func format(expr Expr) string {
+	switch e := expr.(type) {
+	case *And:
+		return fmt.Sprintf("(%s AND %s)", format(e.Left), format(e.Right))
+	case *Or:
+		return fmt.Sprintf("(%s OR %s)", format(e.Left), format(e.Right))
+	case *Not:
+		return "NOT " + format(e.X)
+	case *Comparison:
+		return e.Field + e.Op + e.Value
+	}
+	return "?"
+}
@@ -0,0 +1,271 @@
+// Package filter parses task filter expressions such as
+// "completed:false AND (tag:work OR priority>=high)" and compiles them into
+// parameterized SQL conditions.
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxLength and maxDepth bound the work a single expression can cause
+const (
+	MaxLength = 1000
+	maxDepth  = 20
+)
+
+// Operators of a comparison. ":" matches a value the way that suits the
+// field, e.g. the whole day of a date or part of a title.
+const (
+	OpMatch     = ":"
+	OpEqual     = "="
+	OpNotEqual  = "!="
+	OpLess      = "<"
+	OpLessEqual = "<="
+	OpMore      = ">"
+	OpMoreEqual = ">="
+)
+
+// Error is a syntax or value error at a byte offset of the expression
+type Error struct {
+	Pos     int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Pos)
+}
+
+func errorf(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// Expr is a node of a parsed expression
+type Expr interface {
+	expr()
+}
+
+// And matches tasks that both sides match
+type And struct {
+	Left, Right Expr
+}
+
+// Or matches tasks that either side matches
+type Or struct {
+	Left, Right Expr
+}
+
+// Not matches tasks that X doesn't match
+type Not struct {
+	X Expr
+}
+
+// Comparison compares a field of tasks with a value, e.g. priority>=high
+type Comparison struct {
+	Field    string
+	Op       string
+	Value    string
+	Pos      int // of the field
+	ValuePos int
+}
+
+func (*And) expr()        {}
+func (*Or) expr()         {}
+func (*Not) expr()        {}
+func (*Comparison) expr() {}
+
+// Parse parses an expression. Comparisons are combined with AND, OR and
+// NOT and grouped with parentheses; AND binds tighter than OR and may be
+// left out. Values with spaces or parentheses are quoted, e.g. title:"pay rent".
+func Parse(s string) (Expr, error) {
+	if len(s) > MaxLength {
+		return nil, errorf(MaxLength, "expression is longer than %d characters", MaxLength)
+	}
+
+	p := &parser{src: s}
+	p.skipSpace()
+	if p.done() {
+		return nil, errorf(0, "empty expression")
+	}
+
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		if p.src[p.pos] == ')' {
+			return nil, errorf(p.pos, "unexpected )")
+		}
+		return nil, errorf(p.pos, "expected AND, OR or the end of the expression")
+	}
+	return expr, nil
+}
+
+type parser struct {
+	src   string
+	pos   int
+	depth int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) skipSpace() {
+	for !p.done() && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// keyword consumes the keyword (case-insensitive) if it comes next as a whole word
+func (p *parser) keyword(word string) bool {
+	end := p.pos + len(word)
+	if end > len(p.src) || !strings.EqualFold(p.src[p.pos:end], word) {
+		return false
+	}
+	if end < len(p.src) && !unicode.IsSpace(rune(p.src[end])) && p.src[end] != '(' {
+		return false
+	}
+	p.pos = end
+	p.skipSpace()
+	return true
+}
+
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		start := p.pos
+		if p.keyword("OR") {
+			// Belongs to the enclosing or()
+			p.pos = start
+			return left, nil
+		}
+		if !p.keyword("AND") && (p.done() || p.src[p.pos] == ')') {
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) unary() (Expr, error) {
+	if p.keyword("NOT") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{X: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Expr, error) {
+	if p.done() {
+		return nil, errorf(p.pos, "unexpected end of the expression")
+	}
+
+	if p.src[p.pos] == '(' {
+		open := p.pos
+		if p.depth++; p.depth > maxDepth {
+			return nil, errorf(open, "parentheses are nested more than %d levels deep", maxDepth)
+		}
+		p.pos++
+		p.skipSpace()
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.src[p.pos] != ')' {
+			return nil, errorf(open, "missing closing parenthesis")
+		}
+		p.depth--
+		p.pos++
+		p.skipSpace()
+		return expr, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (Expr, error) {
+	start := p.pos
+	for !p.done() && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos]))) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, errorf(start, "expected a field name")
+	}
+	field := strings.ToLower(p.src[start:p.pos])
+
+	op := ""
+	for _, candidate := range []string{OpLessEqual, OpMoreEqual, OpNotEqual, OpMatch, OpEqual, OpLess, OpMore} {
+		if strings.HasPrefix(p.src[p.pos:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, errorf(p.pos, "expected an operator such as : or >= after %s", field)
+	}
+	p.pos += len(op)
+
+	valuePos := p.pos
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	return &Comparison{Field: field, Op: op, Value: value, Pos: start, ValuePos: valuePos}, nil
+}
+
+// value reads a quoted value or one that ends at a space or parenthesis
+func (p *parser) value() (string, error) {
+	start := p.pos
+	if !p.done() && p.src[p.pos] == '"' {
+		var b strings.Builder
+		for p.pos++; !p.done(); p.pos++ {
+			switch c := p.src[p.pos]; c {
+			case '"':
+				p.pos++
+				return b.String(), nil
+			case '\\':
+				if p.pos+1 < len(p.src) {
+					p.pos++
+				}
+				b.WriteByte(p.src[p.pos])
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errorf(start, "missing closing quote")
+	}
+
+	for !p.done() && !unicode.IsSpace(rune(p.src[p.pos])) && p.src[p.pos] != '(' && p.src[p.pos] != ')' {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", errorf(start, "expected a value")
+	}
+	return p.src[start:p.pos], nil
+}
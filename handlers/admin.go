@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"just-do-it-api/auth"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 200
+)
+
+// likeEscaper escapes the LIKE wildcards in a search, so they match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// adminTargetUser loads the user addressed by /v1/admin/users/{id}[/action]
+func adminTargetUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	userID, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/admin/users/"), "/")
+
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"User not found",
+		))
+		return nil, false
+	}
+	return &user, true
+}
+
+// rejectSelf keeps administrators from locking themselves out
+func rejectSelf(w http.ResponseWriter, r *http.Request, user *models.User) bool {
+	if user.ID != middleware.GetUserID(r) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(models.NewErrorResponse(
+		"Invalid request",
+		"You can't change your own account here",
+	))
+	return true
+}
+
+// withTaskCounts adds the number of (completed) tasks of each user
+func withTaskCounts(users []models.User) ([]models.AdminUser, error) {
+	result := make([]models.AdminUser, len(users))
+	if len(users) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+		result[i].User = user
+	}
+
+	var counts []struct {
+		UserID    uint
+		Total     int64
+		Completed int64
+	}
+	err := db.Where("user_id IN ?", ids).Model(&models.Task{}).
+		Select("user_id, COUNT(*) AS total, SUM(CASE WHEN completed THEN 1 ELSE 0 END) AS completed").
+		Group("user_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, count := range counts {
+		for i := range result {
+			if result[i].ID == count.UserID {
+				result[i].TaskCount = count.Total
+				result[i].CompletedTaskCount = count.Completed
+			}
+		}
+	}
+	return result, nil
+}
+
+// AdminGetUsers lists users. Supports q (email search), role, status
+// (active or disabled), limit and offset query parameters.
+func AdminGetUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultAdminPageSize
+	}
+	if limit > maxAdminPageSize {
+		limit = maxAdminPageSize
+	}
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	tx := db.Where(`LOWER(email) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(query.Get("q")))+"%")
+	if role := query.Get("role"); role != "" {
+		tx = tx.Where("role = ?", role)
+	}
+	switch query.Get("status") {
+	case "active":
+		tx = tx.Where("disabled_at IS NULL")
+	case "disabled":
+		tx = tx.Where("disabled_at IS NOT NULL")
+	}
+	tx = tx.Session(&gorm.Session{})
+
+	var total int64
+	if err := tx.Model(&models.User{}).Count(&total).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to count users",
+		))
+		return
+	}
+
+	var users []models.User
+	if err := tx.Order("id").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch users",
+		))
+		return
+	}
+
+	adminUsers, err := withTaskCounts(users)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to count tasks",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.AdminUsersResponse{
+		Users:  adminUsers,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func AdminGetUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := adminTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	respondAdminUser(w, user)
+}
+
+func AdminDisableUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := adminTargetUser(w, r)
+	if !ok || rejectSelf(w, r, user) {
+		return
+	}
+
+	if !user.Disabled() {
+		now := time.Now()
+		user.DisabledAt = &now
+		if !saveAdminUser(w, user) {
+			return
+		}
+
+		if err := auth.RevokeUserSessions(db, user.ID, ""); err != nil {
+			log.Printf("Failed to revoke sessions of disabled user %d: %v", user.ID, err)
+		}
+		recordAuditEvent(r, &user.ID, models.AuditUserDisabled, adminDetail(r))
+	}
+
+	respondAdminUser(w, user)
+}
+
+func AdminEnableUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := adminTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	if user.Disabled() {
+		user.DisabledAt = nil
+		if !saveAdminUser(w, user) {
+			return
+		}
+		recordAuditEvent(r, &user.ID, models.AuditUserEnabled, adminDetail(r))
+	}
+
+	respondAdminUser(w, user)
+}
+
+// AdminForcePasswordReset logs the user out everywhere and blocks logins until
+// the password is reset with the link that is emailed to them
+func AdminForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	user, ok := adminTargetUser(w, r)
+	if !ok || rejectSelf(w, r, user) {
+		return
+	}
+
+	user.PasswordResetRequired = true
+	if !saveAdminUser(w, user) {
+		return
+	}
+
+	if err := auth.RevokeUserSessions(db, user.ID, ""); err != nil {
+		log.Printf("Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+	if err := sendPasswordResetEmail(r.Context(), user); err != nil {
+		log.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+	}
+	recordAuditEvent(r, &user.ID, models.AuditPasswordResetForced, adminDetail(r))
+
+	respondAdminUser(w, user)
+}
+
+func AdminUpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	user, ok := adminTargetUser(w, r)
+	if !ok || rejectSelf(w, r, user) {
+		return
+	}
+
+	if user.Role != req.Role {
+		previous := user.Role
+		user.Role = req.Role
+		if !saveAdminUser(w, user) {
+			return
+		}
+		recordAuditEvent(r, &user.ID, models.AuditUserRoleChanged, fmt.Sprintf("%s -> %s, %s", previous, req.Role, adminDetail(r)))
+	}
+
+	respondAdminUser(w, user)
+}
+
+func saveAdminUser(w http.ResponseWriter, user *models.User) bool {
+	if err := db.Save(user).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to update user",
+		))
+		return false
+	}
+	return true
+}
+
+func respondAdminUser(w http.ResponseWriter, user *models.User) {
+	adminUsers, err := withTaskCounts([]models.User{*user})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to count tasks",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(adminUsers[0])
+}
+
+// adminDetail names the administrator for the audit trail
+func adminDetail(r *http.Request) string {
+	return fmt.Sprintf("by admin %d", middleware.GetUserID(r))
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"just-do-it-api/auth"
+	"just-do-it-api/mailer"
+	"just-do-it-api/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// adminRequest builds a request of the admin API as sent by the given administrator
+func adminRequest(method, path string, admin models.AuthResponse, payload interface{}) *http.Request {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	return withSession(httptest.NewRequest(method, path, &body), admin.User.ID, "admin-session")
+}
+
+func userPath(user models.User, action string) string {
+	path := "/v1/admin/users/" + strconv.Itoa(int(user.ID))
+	if action != "" {
+		path += "/" + action
+	}
+	return path
+}
+
+func TestAdminGetUsers(t *testing.T) {
+	db = NewAuthMockDB()
+
+	admin := loginTestUser(t, "admin@example.com")
+	alice := loginTestUser(t, "alice@example.com")
+	loginTestUser(t, "bob@example.com")
+	loginTestUser(t, "first_last@example.com")
+
+	if alice.User.Role != models.RoleUser {
+		t.Errorf("expected new users to have role %q, got %q", models.RoleUser, alice.User.Role)
+	}
+
+	for i, completed := range []bool{true, false, false} {
+		task := models.Task{ID: "task-" + strconv.Itoa(i), UserID: alice.User.ID, Title: "Task", Deadline: timePtr(time.Now()), Completed: completed}
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedTotal int64
+	}{
+		{"All Users", "", 4},
+		{"Search By Email", "?q=ALICE", 1},
+		{"Search For Underscore", "?q=_", 1},
+		{"Search For Percent", "?q=%25", 0},
+		{"Search For Backslash", "?q=%5C", 0},
+		{"Filter By Role", "?role=admin", 0},
+		{"Filter By Status", "?status=disabled", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			AdminGetUsers(w, adminRequest(http.MethodGet, "/v1/admin/users"+tt.query, admin, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+
+			var response models.AdminUsersResponse
+			json.NewDecoder(w.Body).Decode(&response)
+			if response.Total != tt.expectedTotal || int64(len(response.Users)) != tt.expectedTotal {
+				t.Errorf("expected %d users, got total %d and %d users", tt.expectedTotal, response.Total, len(response.Users))
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	AdminGetUser(w, adminRequest(http.MethodGet, userPath(alice.User, ""), admin, nil))
+	var user models.AdminUser
+	json.NewDecoder(w.Body).Decode(&user)
+	if user.Email != "alice@example.com" || user.TaskCount != 3 || user.CompletedTaskCount != 1 {
+		t.Errorf("unexpected user %s with %d tasks (%d completed)", user.Email, user.TaskCount, user.CompletedTaskCount)
+	}
+}
+
+func TestAdminDisableUser(t *testing.T) {
+	db = NewAuthMockDB()
+
+	admin := loginTestUser(t, "admin@example.com")
+	user := loginTestUser(t, "test@example.com")
+	credentials := models.LoginRequest{Email: "test@example.com", Password: "password123"}
+
+	w := httptest.NewRecorder()
+	AdminDisableUser(w, adminRequest(http.MethodPost, userPath(user.User, "disable"), admin, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	// Disabling ends the sessions and refuses new logins
+	if w := refresh(user.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the session to be revoked, got %d", w.Code)
+	}
+	if w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", credentials); w.Code != http.StatusForbidden {
+		t.Errorf("expected login of a disabled user to fail with 403, got %d", w.Code)
+	}
+
+	var events []models.AuditEvent
+	db.Where("user_id = ? AND action = ?", user.User.ID, models.AuditUserDisabled).Find(&events)
+	if len(events) != 1 {
+		t.Errorf("expected 1 audit event, got %d", len(events))
+	}
+
+	w = httptest.NewRecorder()
+	AdminEnableUser(w, adminRequest(http.MethodPost, userPath(user.User, "enable"), admin, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", credentials); w.Code != http.StatusOK {
+		t.Errorf("expected login to work again, got %d", w.Code)
+	}
+
+	t.Run("Self", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		AdminDisableUser(w, adminRequest(http.MethodPost, userPath(admin.User, "disable"), admin, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("Unknown User", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		AdminDisableUser(w, adminRequest(http.MethodPost, "/v1/admin/users/999/disable", admin, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestAdminForcePasswordReset(t *testing.T) {
+	db = NewAuthMockDB()
+	outbox := &mailer.OutboxMailer{Dir: t.TempDir()}
+	mail = outbox
+
+	admin := loginTestUser(t, "admin@example.com")
+	user := loginTestUser(t, "test@example.com")
+
+	w := httptest.NewRecorder()
+	AdminForcePasswordReset(w, adminRequest(http.MethodPost, userPath(user.User, "force-password-reset"), admin, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	// Even the right password is refused until the reset
+	if w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "test@example.com", Password: "password123"}); w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", w.Code)
+	}
+
+	token := lastMailToken(t, outbox)
+	if w := handlerRequest(t, ResetPassword, http.MethodPost, "/api/auth/reset-password", models.ResetPasswordRequest{Token: token, Password: "newpassword"}); w.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", w.Code)
+	}
+	if w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "test@example.com", Password: "newpassword"}); w.Code != http.StatusOK {
+		t.Errorf("expected login after the reset to work, got %d", w.Code)
+	}
+}
+
+func TestAdminUpdateUserRole(t *testing.T) {
+	db = NewAuthMockDB()
+
+	admin := loginTestUser(t, "admin@example.com")
+	user := loginTestUser(t, "test@example.com")
+
+	tests := []struct {
+		name           string
+		target         models.User
+		role           string
+		expectedStatus int
+	}{
+		{"Promote", user.User, models.RoleAdmin, http.StatusOK},
+		{"Unknown Role", user.User, "superuser", http.StatusBadRequest},
+		{"Self", admin.User, models.RoleUser, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			AdminUpdateUserRole(w, adminRequest(http.MethodPut, userPath(tt.target, "role"), admin, models.UpdateRoleRequest{Role: tt.role}))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	var updated models.User
+	db.First(&updated, user.User.ID)
+	if updated.Role != models.RoleAdmin {
+		t.Errorf("expected role %q, got %q", models.RoleAdmin, updated.Role)
+	}
+
+	// New tokens carry the role
+	login := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "test@example.com", Password: "password123"})
+	var response models.AuthResponse
+	json.NewDecoder(login.Body).Decode(&response)
+	claims, err := auth.ValidateToken(response.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Role != models.RoleAdmin {
+		t.Errorf("expected role %q in the token, got %q", models.RoleAdmin, claims.Role)
+	}
+}
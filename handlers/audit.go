@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"log"
+	"net/http"
+)
+
+// recordAuditEvent appends an event to the audit trail. Failures are logged
+// but don't fail the request that triggered the event.
+func recordAuditEvent(r *http.Request, userID *uint, action, detail string) {
+	event := models.AuditEvent{
+		UserID:    userID,
+		Action:    action,
+		IPAddress: middleware.ClientIP(r),
+		Detail:    detail,
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("Failed to record audit event %s: %v", action, err)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"just-do-it-api/auth"
 	"just-do-it-api/database"
+	"just-do-it-api/mailer"
 	"just-do-it-api/models"
+	"log"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
@@ -13,11 +15,12 @@ import (
 var (
 	validate = validator.New()
 	db       database.Database
+	mail     mailer.Mailer
 )
 
 func init() {
 	db = database.CreateConnection()
-	db.AutoMigrate(&models.User{})
+	mail = mailer.New()
 }
 
 func Register(w http.ResponseWriter, r *http.Request) {
@@ -58,6 +61,7 @@ func Register(w http.ResponseWriter, r *http.Request) {
 	user := models.User{
 		Email:    req.Email,
 		Password: req.Password,
+		Timezone: req.Timezone,
 	}
 
 	if err := user.HashPassword(); err != nil {
@@ -78,8 +82,10 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := auth.GenerateToken(&user)
+	sendVerificationEmail(r.Context(), &user)
+
+	// Start a session and generate tokens
+	response, err := newAuthResponse(r, &user)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
@@ -90,10 +96,7 @@ func Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(models.AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	json.NewEncoder(w).Encode(response)
 }
 
 func Login(w http.ResponseWriter, r *http.Request) {
@@ -118,10 +121,22 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find user by email
+	// Refuse attempts while the account or the client is throttled
+	limiter := currentLoginLimiter()
+	if !checkLoginAllowed(w, r, limiter, req.Email) {
+		return
+	}
+
+	// Find user by email and check password
 	var user models.User
 	result := db.Where("email = ?", req.Email).First(&user)
-	if result.Error != nil {
+	if result.Error != nil || user.CheckPassword(req.Password) != nil {
+		var userID *uint
+		if result.Error == nil {
+			userID = &user.ID
+		}
+		recordLoginFailure(r, limiter, req.Email, userID)
+
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
 			"Login failed",
@@ -130,18 +145,35 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check password
-	if err := user.CheckPassword(req.Password); err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(models.NewErrorResponse(
-			"Login failed",
-			"Invalid email or password",
-		))
+	if err := limiter.RecordSuccess(req.Email); err != nil {
+		log.Printf("Failed to reset login attempts of user %d: %v", user.ID, err)
+	}
+
+	if !checkAccountActive(w, &user, "Login failed") {
 		return
 	}
 
-	// Generate JWT token
-	token, err := auth.GenerateToken(&user)
+	// With 2FA enabled the password only earns a challenge for the second step
+	if user.TOTPEnabled {
+		mfaToken, err := auth.GenerateActionToken(db, user.ID, auth.PurposeMFAChallenge, mfaChallengeTTL)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Login failed",
+				"Failed to generate token",
+			))
+			return
+		}
+
+		json.NewEncoder(w).Encode(models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
+		return
+	}
+
+	// Start a session and generate tokens
+	response, err := newAuthResponse(r, &user)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
@@ -151,8 +183,29 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(models.AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkAccountActive rejects logins of disabled accounts and of accounts that
+// must reset their password first
+func checkAccountActive(w http.ResponseWriter, user *models.User, errorTitle string) bool {
+	if user.Disabled() {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			errorTitle,
+			"This account has been disabled",
+		))
+		return false
+	}
+
+	if user.PasswordResetRequired {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			errorTitle,
+			"Password reset required, use the link sent to your email",
+		))
+		return false
+	}
+
+	return true
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"just-do-it-api/database"
+	"just-do-it-api/mailer"
 	"just-do-it-api/models"
 	"net/http"
 	"net/http/httptest"
@@ -23,8 +24,10 @@ func NewAuthMockDB() database.Database {
 		panic("failed to connect database")
 	}
 
-	// Initialize database with User model
-	err = db.AutoMigrate(&models.User{})
+	// Initialize database with User and session models
+	err = db.AutoMigrate(&models.User{}, &models.Session{}, &models.RefreshToken{}, &models.ActionToken{}, &models.RecoveryCode{}, &models.PersonalAccessToken{},
+		&models.LoginAttempt{}, &models.AuditEvent{}, &models.UserIdentity{}, &models.OIDCLoginState{}, &models.Task{},
+		&models.DataExport{}, &models.Tag{}, &models.Project{}, &models.Reminder{}, &models.Notification{}, &models.TaskEvent{})
 	if err != nil {
 		panic("failed to migrate database")
 	}
@@ -60,9 +63,18 @@ func (m *AuthMockDB) AutoMigrate(dst ...interface{}) error {
 	return m.db.AutoMigrate(dst...)
 }
 
+func (m *AuthMockDB) Unscoped() *gorm.DB {
+	return m.db.Unscoped()
+}
+
+func (m *AuthMockDB) Transaction(fc func(tx *gorm.DB) error) error {
+	return m.db.Transaction(fc)
+}
+
 func TestRegister(t *testing.T) {
 	mockDB := NewAuthMockDB()
 	db = mockDB
+	mail = &mailer.OutboxMailer{Dir: t.TempDir()}
 
 	tests := []struct {
 		name           string
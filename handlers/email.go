@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"just-do-it-api/auth"
+	"just-do-it-api/config"
+	"just-do-it-api/mailer"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var (
+	// appURL is the base URL of the web app that the links in emails point to
+	appURL = config.String("APP_URL", "http://localhost:3000")
+
+	emailVerificationTTL = config.Duration("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	passwordResetTTL     = config.Duration("PASSWORD_RESET_TTL", time.Hour)
+)
+
+// sendVerificationEmail mails the user a link to confirm their address.
+// Failures are only logged, the user can ask for a new link later.
+func sendVerificationEmail(ctx context.Context, user *models.User) {
+	token, err := auth.GenerateActionToken(db, user.ID, auth.PurposeVerifyEmail, emailVerificationTTL)
+	if err != nil {
+		log.Printf("Failed to generate verification token for user %d: %v", user.ID, err)
+		return
+	}
+
+	err = mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf(
+			"Welcome to Just Do It!\n\nConfirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+			actionLink("/verify-email", token),
+			emailVerificationTTL,
+		),
+	})
+	if err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+}
+
+func sendPasswordResetEmail(ctx context.Context, user *models.User) error {
+	token, err := auth.GenerateActionToken(db, user.ID, auth.PurposeResetPassword, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Someone asked to reset the password of your Just Do It account.\n\nChoose a new password by opening the link below:\n\n%s\n\nThe link expires in %s. If you did not ask for it, you can ignore this email.\n",
+			actionLink("/reset-password", token),
+			passwordResetTTL,
+		),
+	})
+}
+
+func actionLink(path, token string) string {
+	return appURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	userID, err := auth.ConsumeActionToken(db, req.Token, auth.PurposeVerifyEmail)
+	if errors.Is(err, auth.ErrInvalidActionToken) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Verification failed",
+			"Invalid, expired or already used token",
+		))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Verification failed",
+			"Failed to verify token",
+		))
+		return
+	}
+
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Verification failed",
+			"User not found",
+		))
+		return
+	}
+
+	if !user.EmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := db.Save(&user).Error; err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Verification failed",
+				"Failed to update user",
+			))
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(user)
+}
+
+// ResendVerificationEmail sends a new verification link to the authenticated user
+func ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	if err := db.First(&user, middleware.GetUserID(r)).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"User not found",
+		))
+		return
+	}
+
+	if user.EmailVerified() {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Already verified",
+			"Email address is already verified",
+		))
+		return
+	}
+
+	sendVerificationEmail(r.Context(), &user)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ForgotPassword always answers 202 so it can't be used to find out which emails are registered
+func ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	var user models.User
+	if err := db.Where("email = ?", req.Email).First(&user).Error; err == nil {
+		if err := sendPasswordResetEmail(r.Context(), &user); err != nil {
+			log.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	userID, err := auth.ConsumeActionToken(db, req.Token, auth.PurposeResetPassword)
+	if errors.Is(err, auth.ErrInvalidActionToken) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Password reset failed",
+			"Invalid, expired or already used token",
+		))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Password reset failed",
+			"Failed to verify token",
+		))
+		return
+	}
+
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Password reset failed",
+			"User not found",
+		))
+		return
+	}
+
+	user.Password = req.Password
+	if err := user.HashPassword(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Password reset failed",
+			"Failed to hash password",
+		))
+		return
+	}
+
+	user.PasswordResetRequired = false
+
+	// The reset link proved that the user controls the mailbox
+	if !user.EmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := db.Save(&user).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Password reset failed",
+			"Failed to update password",
+		))
+		return
+	}
+
+	// Whoever knew the old password must not stay logged in
+	if err := auth.RevokeUserSessions(db, user.ID, ""); err != nil {
+		log.Printf("Failed to revoke sessions of user %d after password reset: %v", user.ID, err)
+	}
+
+	// Proving control of the mailbox lifts a lockout
+	unlocked, err := currentLoginLimiter().Unlock(user.Email)
+	if err != nil {
+		log.Printf("Failed to unlock user %d after password reset: %v", user.ID, err)
+	}
+	if unlocked {
+		recordAuditEvent(r, &user.ID, models.AuditLoginUnlocked, "Account unlocked by password reset")
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/mailer"
+	"just-do-it-api/models"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+var tokenPattern = regexp.MustCompile(`token=([^\s]+)`)
+
+// lastMailToken returns the token of the link in the most recent email of the outbox
+func lastMailToken(t *testing.T, outbox *mailer.OutboxMailer) string {
+	messages, err := outbox.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) == 0 {
+		t.Fatal("expected an email in the outbox")
+	}
+
+	match := tokenPattern.FindStringSubmatch(messages[len(messages)-1].Body)
+	if match == nil {
+		t.Fatal("expected a token link in the email")
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifyEmail(t *testing.T) {
+	db = NewAuthMockDB()
+	outbox := &mailer.OutboxMailer{Dir: t.TempDir()}
+	mail = outbox
+
+	w := handlerRequest(t, Register, http.MethodPost, "/api/auth/register", models.RegisterRequest{
+		Email:    "test@example.com",
+		Password: "password123",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var registered models.AuthResponse
+	json.NewDecoder(w.Body).Decode(&registered)
+	if registered.User.EmailVerified() {
+		t.Fatal("expected new user to be unverified")
+	}
+
+	token := lastMailToken(t, outbox)
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{
+			name:           "Valid Token",
+			token:          token,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Token Already Used",
+			token:          token,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Token",
+			token:          "invalid",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlerRequest(t, VerifyEmail, http.MethodPost, "/api/auth/verify-email", models.VerifyEmailRequest{Token: tt.token})
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	var user models.User
+	db.First(&user, registered.User.ID)
+	if !user.EmailVerified() {
+		t.Error("expected user to be verified")
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	db = NewAuthMockDB()
+	outbox := &mailer.OutboxMailer{Dir: t.TempDir()}
+	mail = outbox
+
+	login := loginTestUser(t, "test@example.com")
+
+	// Unknown emails are accepted too, but nothing is sent
+	if w := handlerRequest(t, ForgotPassword, http.MethodPost, "/api/auth/forgot-password", models.ForgotPasswordRequest{Email: "nobody@example.com"}); w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+	if messages, _ := outbox.Messages(); len(messages) != 0 {
+		t.Errorf("expected no email for unknown address, got %d", len(messages))
+	}
+
+	if w := handlerRequest(t, ForgotPassword, http.MethodPost, "/api/auth/forgot-password", models.ForgotPasswordRequest{Email: "test@example.com"}); w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+	token := lastMailToken(t, outbox)
+
+	// A verification token can't be used to reset a password
+	if w := handlerRequest(t, VerifyEmail, http.MethodPost, "/api/auth/verify-email", models.VerifyEmailRequest{Token: token}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected reset token to be rejected for verification, got %d", w.Code)
+	}
+
+	w := handlerRequest(t, ResetPassword, http.MethodPost, "/api/auth/reset-password", models.ResetPasswordRequest{Token: token, Password: "newpassword"})
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	if w := handlerRequest(t, ResetPassword, http.MethodPost, "/api/auth/reset-password", models.ResetPasswordRequest{Token: token, Password: "another"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected reused token to be rejected, got %d", w.Code)
+	}
+
+	// Existing sessions are revoked and only the new password works
+	if w := refresh(login.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected old session to be revoked, got %d", w.Code)
+	}
+	if w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "test@example.com", Password: "password123"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected old password to fail, got %d", w.Code)
+	}
+	if w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "test@example.com", Password: "newpassword"}); w.Code != http.StatusOK {
+		t.Errorf("expected new password to work, got %d", w.Code)
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"just-do-it-api/config"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// requireIfMatch makes clients send If-Match when they change or delete a
+// task, so they can't overwrite changes they haven't seen
+var requireIfMatch = config.Bool("REQUIRE_IF_MATCH", false)
+
+// errVersionConflict is returned when a task changed since it was read
+var errVersionConflict = errors.New("task changed since it was read")
+
+// taskETag identifies a task as responses render it. Besides the version it
+// covers what changes without one: the tags, the progress of the subtasks
+// and the time zone of the deadline.
+func taskETag(task *models.Task, loc *time.Location) string {
+	tags := make([]models.Tag, len(task.Tags))
+	copy(tags, task.Tags)
+	sort.Slice(tags, func(i, j int) bool { return tags[i].ID < tags[j].ID })
+
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%s", loc)
+	for _, tag := range tags {
+		fmt.Fprintf(hash, "|%d:%s:%s", tag.ID, tag.Name, tag.Color)
+	}
+	if task.Progress != nil {
+		fmt.Fprintf(hash, "|%d/%d", task.Progress.Done, task.Progress.Total)
+	}
+	return fmt.Sprintf(`"%d-%x"`, task.Version, hash.Sum64())
+}
+
+// writeTask responds with the task and its ETag
+func writeTask(w http.ResponseWriter, status int, loc *time.Location, task *models.Task) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", taskETag(task, loc))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(task)
+}
+
+// renderedTask loads a task the way responses show it: with its tags, the
+// progress of its subtasks and the deadline in the user's time zone
+func renderedTask(db database.Database, loc *time.Location, taskID string) (*models.Task, error) {
+	var task models.Task
+	if err := db.Where("id = ?", taskID).Preload("Tags").First(&task).Error; err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{task}
+	if err := withProgress(db, tasks); err != nil {
+		return nil, err
+	}
+	task = tasks[0]
+	localizeTask(loc, &task)
+	return &task, nil
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header lists the
+// ETag. Weak ETags only match when weak is set.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch makes sure the client changes the version of the task it has
+// seen. Otherwise it answers with the current task.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, db database.Database, loc *time.Location, task *models.Task) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if !requireIfMatch {
+			return true
+		}
+		w.WriteHeader(http.StatusPreconditionRequired)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Precondition required",
+			"Send the ETag of the task in If-Match",
+		))
+		return false
+	}
+
+	current, err := renderedTask(db, loc, task.ID)
+	if err == nil && etagMatches(header, taskETag(current, loc), false) {
+		return true
+	}
+	writeVersionConflict(w, db, loc, task.ID)
+	return false
+}
+
+// writeVersionConflict answers a write to an outdated version of a task with
+// the current one
+func writeVersionConflict(w http.ResponseWriter, db database.Database, loc *time.Location, taskID string) {
+	task, err := renderedTask(db, loc, taskID)
+	if err != nil {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Precondition failed",
+			"Task changed since it was read",
+		))
+		return
+	}
+	writeTask(w, http.StatusPreconditionFailed, loc, task)
+}
+
+// saveTask writes the task unless it changed since it was read, and moves it
+// to the next version
+func saveTask(tx *gorm.DB, task *models.Task) error {
+	version := task.Version
+	task.Version++
+	result := tx.Model(task).Where("version = ?", version).Select("*").Omit("Tags", "User", "CreatedAt").Updates(task)
+	if result.Error != nil {
+		task.Version = version
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		task.Version = version
+		return errVersionConflict
+	}
+	return nil
+}
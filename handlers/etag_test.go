@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestTaskETags(t *testing.T) {
+	setupTest(t)
+	createTaggedTask(t, "versioned")
+
+	rr := handlerRequest(t, GetTask, http.MethodGet, "/v1/tasks/versioned", nil)
+	first := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etagVersion(first) != "1" {
+		t.Fatalf("expected the first version, got %d with ETag %q", rr.Code, first)
+	}
+	if rr := handlerRequest(t, GetTask, http.MethodGet, "/v1/tasks/versioned", nil, "If-None-Match", "W/"+first); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected status %d without a body, got %d: %s", http.StatusNotModified, rr.Code, rr.Body.String())
+	}
+
+	update := map[string]interface{}{"title": "Updated"}
+	rr = handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/versioned", update, "If-Match", first)
+	second := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etagVersion(second) != "2" {
+		t.Fatalf("expected the update to make version 2, got %d with ETag %q: %s", rr.Code, second, rr.Body.String())
+	}
+	if rr := handlerRequest(t, GetTask, http.MethodGet, "/v1/tasks/versioned", nil, "If-None-Match", first); rr.Code != http.StatusOK {
+		t.Errorf("expected status %d for a changed task, got %d", http.StatusOK, rr.Code)
+	}
+
+	// The other device still has version 1
+	rr = handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/versioned", map[string]interface{}{"title": "Stale"}, "If-Match", first)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusPreconditionFailed, rr.Code, rr.Body.String())
+	}
+	var current models.Task
+	if err := json.NewDecoder(rr.Body).Decode(&current); err != nil {
+		t.Fatal(err)
+	}
+	if current.Title != "Updated" || current.Version != 2 || rr.Header().Get("ETag") != second {
+		t.Errorf("expected the current task with its ETag, got %+v with ETag %q", current, rr.Header().Get("ETag"))
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		path    string
+		payload interface{}
+	}{
+		{"Patch", PatchTask, http.MethodPatch, "/v1/tasks/versioned", map[string]interface{}{"title": "Stale"}},
+		{"Toggle", ToggleTask, http.MethodPatch, "/v1/tasks/versioned/toggle", nil},
+		{"Delete", DeleteTask, http.MethodDelete, "/v1/tasks/versioned", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := handlerRequest(t, tt.handler, tt.method, tt.path, tt.payload, "If-Match", first)
+			if rr.Code != http.StatusPreconditionFailed {
+				t.Errorf("expected status %d, got %d: %s", http.StatusPreconditionFailed, rr.Code, rr.Body.String())
+			}
+		})
+	}
+	if task := loadTask(t, "versioned"); task.Title != "Updated" || task.Completed || task.DeletedAt.Valid {
+		t.Fatalf("expected stale writes to leave the task alone, got %+v", task)
+	}
+
+	rr = handlerRequest(t, ToggleTask, http.MethodPatch, "/v1/tasks/versioned/toggle", nil, "If-Match", `"0", `+second)
+	third := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etagVersion(third) != "3" {
+		t.Fatalf("expected the toggle to make version 3, got %d with ETag %q: %s", rr.Code, third, rr.Body.String())
+	}
+	rr = handlerRequest(t, PatchTask, http.MethodPatch, "/v1/tasks/versioned", map[string]interface{}{"description": "Any version"}, "If-Match", "*")
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected If-Match: * to match, got %d: %s", rr.Code, rr.Body.String())
+	}
+	beforeDelete := rr.Header().Get("ETag")
+	if rr := handlerRequest(t, DeleteTask, http.MethodDelete, "/v1/tasks/versioned", nil, "If-Match", beforeDelete); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+	if task := loadTask(t, "versioned"); !task.DeletedAt.Valid || task.Version != 5 {
+		t.Errorf("expected the delete to make version 5, got version %d", task.Version)
+	}
+
+	// A restored task doesn't match the ETag it had before it was deleted
+	if rr := handlerRequest(t, RestoreTask, http.MethodPost, "/v1/tasks/versioned/restore", nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	rr = handlerRequest(t, PatchTask, http.MethodPatch, "/v1/tasks/versioned", map[string]interface{}{"title": "Stale"}, "If-Match", beforeDelete)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status %d for an ETag from before the delete, got %d: %s", http.StatusPreconditionFailed, rr.Code, rr.Body.String())
+	}
+}
+
+// TestTaskETagsFollowRelatedData checks that changes a task's version doesn't
+// count still change its ETag
+func TestTaskETagsFollowRelatedData(t *testing.T) {
+	setupTest(t)
+	tags := map[string]uint{}
+	for _, tag := range createTaggedTask(t, "tagged", "client", "home").Tags {
+		tags[tag.Name] = tag.ID
+	}
+	if rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{"id": "step", "title": "Step", "parent_id": "tagged"}); rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	client, home := tags["client"], tags["home"]
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		path    string
+		payload interface{}
+	}{
+		{"Rename tag", UpdateTag, http.MethodPut, fmt.Sprintf("/v1/tags/%d", client), models.TagRequest{Name: "customer"}},
+		{"Recolor tag", UpdateTag, http.MethodPut, fmt.Sprintf("/v1/tags/%d", client), models.TagRequest{Name: "customer", Color: "#ff0000"}},
+		{"Merge tag", MergeTag, http.MethodPost, fmt.Sprintf("/v1/tags/%d/merge", home), models.MergeTagRequest{TargetID: client}},
+		{"Delete tag", DeleteTag, http.MethodDelete, fmt.Sprintf("/v1/tags/%d", client), nil},
+		{"Complete subtask", ToggleTask, http.MethodPatch, "/v1/tasks/step/toggle", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			etag := handlerRequest(t, GetTask, http.MethodGet, "/v1/tasks/tagged", nil).Header().Get("ETag")
+			if rr := handlerRequest(t, tt.handler, tt.method, tt.path, tt.payload); rr.Code >= http.StatusBadRequest {
+				t.Fatalf("expected the change to succeed, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			rr := handlerRequest(t, GetTask, http.MethodGet, "/v1/tasks/tagged", nil, "If-None-Match", etag)
+			if rr.Code != http.StatusOK {
+				t.Errorf("expected status %d for a changed task, got %d", http.StatusOK, rr.Code)
+			}
+			if rr.Header().Get("ETag") == etag {
+				t.Errorf("expected a new ETag, got %q again", etag)
+			}
+		})
+	}
+}
+
+func TestTaskETagsFollowTimezone(t *testing.T) {
+	setupTest(t)
+	createTaggedTask(t, "zoned")
+
+	etag := handlerRequest(t, GetTask, http.MethodGet, "/v1/tasks/zoned?tz=UTC", nil).Header().Get("ETag")
+	rr := handlerRequest(t, GetTask, http.MethodGet, "/v1/tasks/zoned?tz=Asia/Tokyo", nil, "If-None-Match", etag)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") == etag {
+		t.Errorf("expected the deadline in another zone to have its own ETag, got %d with %q", rr.Code, rr.Header().Get("ETag"))
+	}
+	if rr := handlerRequest(t, GetTask, http.MethodGet, "/v1/tasks/zoned?tz=UTC", nil, "If-None-Match", etag); rr.Code != http.StatusNotModified {
+		t.Errorf("expected status %d in the same zone, got %d", http.StatusNotModified, rr.Code)
+	}
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	setupTest(t)
+	createTaggedTask(t, "guarded")
+
+	defer func(required bool) { requireIfMatch = required }(requireIfMatch)
+	requireIfMatch = true
+
+	rr := handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/guarded", map[string]interface{}{"title": "Blind"})
+	if rr.Code != http.StatusPreconditionRequired {
+		t.Errorf("expected status %d, got %d: %s", http.StatusPreconditionRequired, rr.Code, rr.Body.String())
+	}
+	etag := handlerRequest(t, GetTask, http.MethodGet, "/v1/tasks/guarded", nil).Header().Get("ETag")
+	rr = handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/guarded", map[string]interface{}{"title": "Seen"}, "If-Match", etag)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+}
+
+func TestSaveTaskDetectsConcurrentWrites(t *testing.T) {
+	setupTest(t)
+	createTaggedTask(t, "raced")
+
+	// Both devices read version 1, the phone saves first
+	web := loadTask(t, "raced")
+	phone := loadTask(t, "raced")
+	db := database.CreateConnection()
+	save := func(task *models.Task) error {
+		return db.Transaction(func(tx *gorm.DB) error { return saveTask(tx, task) })
+	}
+
+	phone.Title = "From the phone"
+	if err := save(&phone); err != nil || phone.Version != 2 {
+		t.Fatalf("expected the first write to make version 2, got version %d: %v", phone.Version, err)
+	}
+	web.Title = "From the web"
+	if err := save(&web); !errors.Is(err, errVersionConflict) || web.Version != 1 {
+		t.Errorf("expected a version conflict, got version %d: %v", web.Version, err)
+	}
+	if task := loadTask(t, "raced"); task.Title != "From the phone" {
+		t.Errorf("expected the first write to be kept, got %q", task.Title)
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"just-do-it-api/config"
+	"just-do-it-api/export"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// apiURL is the public base URL of this API, used for download links
+	apiURL = config.String("API_URL", "http://localhost:8080")
+
+	exportURLTTL = config.Duration("EXPORT_URL_TTL", 15*time.Minute)
+
+	// runExport builds an export in the background. Tests replace it to run synchronously.
+	runExport = func(exportID string) {
+		go func() {
+			if err := export.Run(context.Background(), db, exportID); err != nil {
+				log.Printf("Failed to run data export: %v", err)
+			}
+		}()
+	}
+)
+
+func exportResponse(dataExport models.DataExport) models.DataExportResponse {
+	response := models.DataExportResponse{DataExport: dataExport}
+	if dataExport.Status != models.ExportCompleted {
+		return response
+	}
+
+	expires := time.Now().Add(exportURLTTL)
+	if dataExport.ExpiresAt != nil && dataExport.ExpiresAt.Before(expires) {
+		expires = *dataExport.ExpiresAt
+	}
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("signature", export.Sign(dataExport.ID, expires))
+	response.DownloadURL = apiURL + "/v1/me/export/" + dataExport.ID + "/download?" + query.Encode()
+	response.DownloadURLExpiresAt = &expires
+	return response
+}
+
+// CreateDataExport starts building an archive with all data of the user. A
+// running export is returned instead of starting another one.
+func CreateDataExport(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+
+	var dataExport models.DataExport
+	err := db.Where("user_id = ? AND status IN ?", userID, []string{models.ExportPending, models.ExportRunning}).First(&dataExport).Error
+	if err != nil {
+		exportID, err := export.NewID()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Internal server error",
+				"Failed to create export",
+			))
+			return
+		}
+
+		dataExport = models.DataExport{
+			ID:     exportID,
+			UserID: userID,
+			Status: models.ExportPending,
+		}
+		if err := db.Create(&dataExport).Error; err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Internal server error",
+				"Failed to create export",
+			))
+			return
+		}
+
+		runExport(dataExport.ID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/v1/me/export/"+dataExport.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(exportResponse(dataExport))
+}
+
+func GetDataExports(w http.ResponseWriter, r *http.Request) {
+	var exports []models.DataExport
+	if err := db.Where("user_id = ?", middleware.GetUserID(r)).Order("created_at DESC").Find(&exports).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch exports",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.DataExportsResponse{Exports: exports})
+}
+
+// GetDataExport returns the status of an export, with a fresh download URL once it is completed
+func GetDataExport(w http.ResponseWriter, r *http.Request) {
+	exportID := strings.TrimPrefix(r.URL.Path, "/v1/me/export/")
+
+	var dataExport models.DataExport
+	if err := db.Where("id = ? AND user_id = ?", exportID, middleware.GetUserID(r)).First(&dataExport).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Export not found",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exportResponse(dataExport))
+}
+
+// DownloadDataExport serves the archive. It needs no token: the signed URL
+// handed out by GetDataExport authorizes the download until it expires.
+func DownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	exportID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/me/export/"), "/download")
+	query := r.URL.Query()
+
+	if !export.Verify(exportID, query.Get("expires"), query.Get("signature"), time.Now()) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Forbidden",
+			"Invalid or expired download link",
+		))
+		return
+	}
+
+	var dataExport models.DataExport
+	err := db.Where("id = ? AND status = ?", exportID, models.ExportCompleted).First(&dataExport).Error
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Export not found",
+		))
+		return
+	}
+
+	file, err := os.Open(export.Path(dataExport.ID))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Gone",
+			"The archive is no longer available",
+		))
+		return
+	}
+	defer file.Close()
+
+	name := "just-do-it-export-" + dataExport.CreatedAt.Format("2006-01-02") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Header().Set("Cache-Control", "private, no-store")
+	var modTime time.Time
+	if dataExport.CompletedAt != nil {
+		modTime = *dataExport.CompletedAt
+	}
+	http.ServeContent(w, r, name, modTime, file)
+}
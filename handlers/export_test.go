@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"just-do-it-api/export"
+	"just-do-it-api/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDataExport(t *testing.T) {
+	db = NewAuthMockDB()
+	export.Dir = t.TempDir()
+	runExport = func(exportID string) {
+		if err := export.Run(context.Background(), db, exportID); err != nil {
+			t.Errorf("export failed: %v", err)
+		}
+	}
+
+	login := loginTestUser(t, "test@example.com")
+	other := loginTestUser(t, "other@example.com")
+
+	for _, task := range []models.Task{
+		{ID: "1", UserID: login.User.ID, Title: "Kept", Deadline: timePtr(time.Now())},
+		{ID: "2", UserID: login.User.ID, Title: "Deleted", Deadline: timePtr(time.Now())},
+		{ID: "3", UserID: other.User.ID, Title: "Someone else's", Deadline: timePtr(time.Now())},
+	} {
+		db.Create(&task)
+	}
+	db.Delete(&models.Task{}, "id = ?", "2")
+
+	w := httptest.NewRecorder()
+	CreateDataExport(w, meRequest(t, http.MethodPost, "/v1/me/export", login, nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", w.Code)
+	}
+	var created models.DataExportResponse
+	json.NewDecoder(w.Body).Decode(&created)
+
+	// The status is polled until the archive is ready
+	w = httptest.NewRecorder()
+	GetDataExport(w, meRequest(t, http.MethodGet, "/v1/me/export/"+created.ID, login, nil))
+	var status models.DataExportResponse
+	json.NewDecoder(w.Body).Decode(&status)
+	if status.Status != models.ExportCompleted || status.DownloadURL == "" {
+		t.Fatalf("expected a completed export with a download URL, got %+v", status)
+	}
+
+	w = httptest.NewRecorder()
+	GetDataExport(w, meRequest(t, http.MethodGet, "/v1/me/export/"+created.ID, other, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected other users to get 404, got %d", w.Code)
+	}
+
+	downloadURL, _ := url.Parse(status.DownloadURL)
+	download := func(query url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		DownloadDataExport(w, httptest.NewRequest(http.MethodGet, downloadURL.Path+"?"+query.Encode(), nil))
+		return w
+	}
+
+	t.Run("Invalid Signature", func(t *testing.T) {
+		query := downloadURL.Query()
+		query.Set("signature", "forged")
+		if w := download(query); w.Code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("Extended Expiry", func(t *testing.T) {
+		query := downloadURL.Query()
+		query.Set("expires", "4102444800")
+		if w := download(query); w.Code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", w.Code)
+		}
+	})
+
+	w = download(downloadURL.Query())
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("expected a zip download, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		rc, _ := file.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[file.Name] = string(content)
+	}
+
+	for _, name := range []string{"profile.json", "profile.csv", "tasks.json", "tasks.csv", "projects.json", "projects.csv", "sessions.json", "sessions.csv", "audit_events.json", "audit_events.csv"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected %s in the archive", name)
+		}
+	}
+
+	var tasks []map[string]interface{}
+	json.Unmarshal([]byte(files["tasks.json"]), &tasks)
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks including the deleted one, got %d", len(tasks))
+	}
+	if tasks[1]["deleted_at"] == nil || tasks[0]["created_at"] == nil {
+		t.Errorf("expected hidden task fields to be exported, got %v", tasks)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(files["tasks.csv"])).ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("expected a header and 2 rows in tasks.csv, got %d (%v)", len(rows), err)
+	}
+	if header := strings.Join(rows[0], ","); !strings.Contains(header, "title") || !strings.Contains(header, "deleted_at") {
+		t.Errorf("unexpected tasks.csv header %q", header)
+	}
+
+	if strings.Contains(files["profile.json"], "$2a$") || strings.Contains(files["profile.csv"], "$2a$") {
+		t.Error("the password hash must not be exported")
+	}
+}
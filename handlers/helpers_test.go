@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// handlerRequest runs a handler without a session, which is user 0. The
+// payload is sent as JSON, or as is when it is a string. headers are name,
+// value pairs that replace the application/json Content-Type; pairs with an
+// empty value are left out.
+func handlerRequest(t *testing.T, handler http.HandlerFunc, method, path string, payload interface{}, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	switch payload := payload.(type) {
+	case nil:
+	case string:
+		body.WriteString(payload)
+	default:
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] != "" {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+	}
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
+
+// zonedRequest runs a handler as the user, optionally overriding the time zone with the X-Timezone header
+func zonedRequest(t *testing.T, handler http.HandlerFunc, path string, userID uint, header string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := withSession(httptest.NewRequest(http.MethodGet, path, nil), userID, "")
+	if header != "" {
+		req.Header.Set(TimezoneHeader, header)
+	}
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
+
+func createTaggedTask(t *testing.T, id string, tags ...string) models.Task {
+	t.Helper()
+
+	rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{
+		"id":       id,
+		"title":    "Task " + id,
+		"deadline": time.Now().Add(24 * time.Hour),
+		"tags":     tags,
+	})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d creating the task, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	var task models.Task
+	if err := json.NewDecoder(rr.Body).Decode(&task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func deleteTask(t *testing.T, id string) {
+	t.Helper()
+
+	if rr := handlerRequest(t, DeleteTask, http.MethodDelete, "/v1/tasks/"+id, nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d deleting %s, got %d: %s", http.StatusNoContent, id, rr.Code, rr.Body.String())
+	}
+}
+
+// loadTask reads a task and its tags from the database, even from the trash
+func loadTask(t *testing.T, id string) models.Task {
+	t.Helper()
+
+	var task models.Task
+	if err := database.CreateConnection().Unscoped().Where("id = ?", id).Preload("Tags").First(&task).Error; err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// responseTaskIDs returns the IDs of the tasks in a listing in their order
+func responseTaskIDs(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Tasks []struct {
+			ID string `json:"id"`
+		} `json:"tasks"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, len(response.Tasks))
+	for i, task := range response.Tasks {
+		ids[i] = task.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+// taskIDs returns the IDs of the tasks a listing returns, sorted
+func taskIDs(t *testing.T, handler http.HandlerFunc, path string) string {
+	t.Helper()
+
+	ids := strings.Split(responseTaskIDs(t, handlerRequest(t, handler, http.MethodGet, path, nil)), ",")
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// trashIDs returns the IDs of the tasks in the trash, most recently deleted first
+func trashIDs(t *testing.T) string {
+	t.Helper()
+	return responseTaskIDs(t, handlerRequest(t, GetTrash, http.MethodGet, "/v1/trash", nil))
+}
+
+// etagVersion returns the version an ETag was made for
+func etagVersion(etag string) string {
+	version, _, _ := strings.Cut(strings.Trim(etag, `"`), "-")
+	return version
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"just-do-it-api/database"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// taskSnapshot returns the fields of a task as the history compares them:
+// deadlines in UTC and tags by name, without the ones that change on their own
+func taskSnapshot(task *models.Task) map[string]interface{} {
+	snapshot := *task
+	if snapshot.Deadline != nil {
+		deadline := snapshot.Deadline.UTC()
+		snapshot.Deadline = &deadline
+	}
+	snapshot.Tags = nil
+	snapshot.Progress = nil
+
+	fields := map[string]interface{}{}
+	if data, err := json.Marshal(snapshot); err == nil {
+		json.Unmarshal(data, &fields)
+	}
+	for _, field := range []string{"id", "user_id", "version", "progress"} {
+		delete(fields, field)
+	}
+
+	names := make([]string, len(task.Tags))
+	for i, tag := range task.Tags {
+		names[i] = tag.Name
+	}
+	sort.Strings(names)
+	tags := make([]interface{}, len(names))
+	for i, name := range names {
+		tags[i] = name
+	}
+	fields["tags"] = tags
+	return fields
+}
+
+// diffTasks returns the fields that differ between two snapshots
+func diffTasks(before, after map[string]interface{}) models.TaskChanges {
+	changes := models.TaskChanges{}
+	for field, value := range after {
+		if !reflect.DeepEqual(before[field], value) {
+			changes[field] = models.FieldChange{Before: before[field], After: value}
+		}
+	}
+	for field, value := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = models.FieldChange{Before: value}
+		}
+	}
+	return changes
+}
+
+// initialFields returns the fields a new task was created with, leaving out
+// empty ones
+func initialFields(task *models.Task) models.TaskChanges {
+	changes := models.TaskChanges{}
+	for field, value := range taskSnapshot(task) {
+		if value == nil || value == "" || value == false || value == float64(0) {
+			continue
+		}
+		if list, ok := value.([]interface{}); ok && len(list) == 0 {
+			continue
+		}
+		changes[field] = models.FieldChange{After: value}
+	}
+	return changes
+}
+
+// recordTaskEvent appends an event of the request to the history of a task.
+// It runs in the transaction of the change, so either both are saved or neither.
+func recordTaskEvent(tx *gorm.DB, r *http.Request, taskID string, userID uint, action string, changes models.TaskChanges) error {
+	return tx.Create(&models.TaskEvent{
+		TaskID:    taskID,
+		UserID:    userID,
+		ActorID:   middleware.GetUserID(r),
+		Action:    action,
+		Changes:   changes,
+		RequestID: middleware.GetRequestID(r),
+	}).Error
+}
+
+// taskEvents returns the events of the task the user has under taskID,
+// oldest first, and whether there is such a task. Task IDs can be used again
+// once a task is purged, so the history starts when the current task was
+// created. Without a task the history of the last purged one is returned.
+func taskEvents(db database.Database, taskID string, userID uint) ([]models.TaskEvent, bool, error) {
+	query := db.Where("task_id = ? AND user_id = ?", taskID, userID)
+
+	var task models.Task
+	err := db.Unscoped().Where("id = ? AND user_id = ?", taskID, userID).Select("id", "created_at").First(&task).Error
+	switch {
+	case err == nil:
+		query = query.Where("created_at >= ?", task.CreatedAt)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		var created models.TaskEvent
+		err := db.Where("task_id = ? AND user_id = ? AND action = ?", taskID, userID, models.TaskCreated).Order("id DESC").First(&created).Error
+		if err == nil {
+			query = query.Where("id >= ?", created.ID)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+	default:
+		return nil, false, err
+	}
+
+	events := []models.TaskEvent{}
+	if err := query.Order("id").Find(&events).Error; err != nil {
+		return nil, false, err
+	}
+	return events, task.ID != "" || len(events) > 0, nil
+}
+
+// GetTaskHistory lists the changes of a task, oldest first. Tasks in the
+// trash and tasks purged from it keep their history.
+func GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	taskID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/tasks/"), "/history")
+
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	events, found, err := taskEvents(db, taskID, middleware.GetUserID(r))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch task history",
+		))
+		return
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Task not found",
+		))
+		return
+	}
+
+	for i := range events {
+		events[i].CreatedAt = events[i].CreatedAt.In(loc)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.TaskHistoryResponse{Events: events})
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/database"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func taskHistory(t *testing.T, id string) []models.TaskEvent {
+	t.Helper()
+
+	rr := handlerRequest(t, GetTaskHistory, http.MethodGet, "/v1/tasks/"+id+"/history", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response models.TaskHistoryResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return response.Events
+}
+
+func historyActions(events []models.TaskEvent) string {
+	actions := make([]string, len(events))
+	for i, event := range events {
+		actions[i] = event.Action
+	}
+	return strings.Join(actions, ",")
+}
+
+func TestTaskHistory(t *testing.T) {
+	setupTest(t)
+	createTaggedTask(t, "report", "work")
+
+	// Moving the deadline records where it was and where it went
+	deadline := time.Date(2030, time.March, 1, 17, 0, 0, 0, time.UTC)
+	update := map[string]interface{}{"title": "Task report", "deadline": deadline, "tags": []string{"work", "urgent"}}
+	if rr := handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/report", update); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if rr := handlerRequest(t, ToggleTask, http.MethodPatch, "/v1/tasks/report/toggle", nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	deleteTask(t, "report")
+	if rr := handlerRequest(t, RestoreTask, http.MethodPost, "/v1/tasks/report/restore", nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	events := taskHistory(t, "report")
+	if got := historyActions(events); got != "created,updated,toggled,deleted,restored" {
+		t.Fatalf("expected every change in order, got %q", got)
+	}
+
+	created := events[0].Changes
+	if created["title"].After != "Task report" || created["completed"].After != nil {
+		t.Errorf("expected the created event to list the non-empty fields, got %+v", created)
+	}
+
+	updated := events[1].Changes
+	if len(updated) != 2 {
+		t.Errorf("expected only the deadline and tags to change, got %+v", updated)
+	}
+	if before, _ := updated["deadline"].Before.(string); before == "" {
+		t.Errorf("expected the previous deadline, got %+v", updated["deadline"])
+	}
+	if after, _ := updated["deadline"].After.(string); after != deadline.Format(time.RFC3339) {
+		t.Errorf("expected the new deadline %s, got %v", deadline.Format(time.RFC3339), updated["deadline"].After)
+	}
+	if tags, _ := json.Marshal(updated["tags"]); string(tags) != `{"before":["work"],"after":["urgent","work"]}` {
+		t.Errorf("expected the tags before and after, got %s", tags)
+	}
+
+	toggled := events[2].Changes
+	if toggled["completed"].Before != false || toggled["completed"].After != true {
+		t.Errorf("expected the toggle to record the completion, got %+v", toggled)
+	}
+	for _, event := range events {
+		if event.TaskID != "report" || event.ActorID != 0 || event.CreatedAt.IsZero() {
+			t.Errorf("expected each event to name the task, actor and time, got %+v", event)
+		}
+	}
+}
+
+func TestTaskHistoryOfSubtasks(t *testing.T) {
+	setupTest(t)
+
+	tasks := []map[string]interface{}{
+		{"id": "move", "title": "Move"},
+		{"id": "boxes", "title": "Pack boxes", "parent_id": "move"},
+	}
+	for _, task := range tasks {
+		if rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", task); rr.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+	}
+
+	defer func(mode string) { completionMode = mode }(completionMode)
+	completionMode = CompletionCascade
+	if rr := handlerRequest(t, ToggleTask, http.MethodPatch, "/v1/tasks/move/toggle", nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	deleteTask(t, "move")
+
+	if got := historyActions(taskHistory(t, "boxes")); got != "created,toggled,deleted" {
+		t.Errorf("expected the cascaded changes in the subtask's history, got %q", got)
+	}
+}
+
+func TestTaskHistoryOutlivesPurge(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+	createTaggedTask(t, "shared")
+	createTaggedTask(t, "mine")
+
+	if rr := handlerRequest(t, PatchTask, http.MethodPatch, "/v1/tasks/shared", map[string]interface{}{"description": "Secret plans"}); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	for _, id := range []string{"shared", "mine"} {
+		deleteTask(t, id)
+		if rr := handlerRequest(t, PurgeTrashedTask, http.MethodDelete, "/v1/trash/"+id, nil); rr.Code != http.StatusNoContent {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+		}
+	}
+	if got := historyActions(taskHistory(t, "shared")); got != "created,updated,deleted" {
+		t.Errorf("expected the history to be kept after the task is purged, got %q", got)
+	}
+
+	// A task that reuses the ID of a purged one starts a history of its own
+	if rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{"id": "mine", "title": "Mine again"}); rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if got := historyActions(taskHistory(t, "mine")); got != "created" {
+		t.Errorf("expected only the new task's history, got %q", got)
+	}
+
+	// Even when another user reuses it
+	other := models.User{Email: "other-history@example.com", Password: "hash"}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	req := withSession(httptest.NewRequest(http.MethodPost, "/v1/tasks", strings.NewReader(`{"id": "shared", "title": "Theirs"}`)), other.ID, "")
+	rr := httptest.NewRecorder()
+	CreateTask(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	rr = zonedRequest(t, GetTaskHistory, "/v1/tasks/shared/history", other.ID, "")
+	var response models.TaskHistoryResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if got := historyActions(response.Events); rr.Code != http.StatusOK || got != "created" {
+		t.Fatalf("expected only the other user's history, got %d with %q", rr.Code, got)
+	}
+	if changes := response.Events[0].Changes; changes["title"].After != "Theirs" || changes["description"].After != nil {
+		t.Errorf("expected the other user's task in their history, got %+v", changes)
+	}
+	if got := historyActions(taskHistory(t, "shared")); got != "created,updated,deleted" {
+		t.Errorf("expected the purged history to stay with its owner, got %q", got)
+	}
+}
+
+func TestTaskHistoryRequestID(t *testing.T) {
+	setupTest(t)
+	createTaggedTask(t, "traced")
+
+	body := strings.NewReader(`{"title": "Traced"}`)
+	req := httptest.NewRequest(http.MethodPatch, "/v1/tasks/traced", body)
+	req.Header.Set(middleware.RequestIDHeader, "client-42")
+	rr := httptest.NewRecorder()
+	middleware.Logger(PatchTask)(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get(middleware.RequestIDHeader) != "client-42" {
+		t.Fatalf("expected the request ID to be echoed, got %d with %q", rr.Code, rr.Header().Get(middleware.RequestIDHeader))
+	}
+
+	events := taskHistory(t, "traced")
+	if len(events) != 2 || events[1].RequestID != "client-42" {
+		t.Errorf("expected the patch to carry the request ID, got %+v", events)
+	}
+}
+
+func TestTaskHistoryNotFound(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	foreign := models.Task{ID: "foreign", UserID: 42, Title: "Someone else's"}
+	if err := db.Create(&foreign).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"foreign", "missing"} {
+		if rr := handlerRequest(t, GetTaskHistory, http.MethodGet, "/v1/tasks/"+id+"/history", nil); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d for %s, got %d", http.StatusNotFound, id, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/auth"
+	"net/http"
+)
+
+// JWKS publishes the public keys that tokens are signed with so other services can verify them
+func JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(auth.Keys().JWKS())
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+var errCursorSort = errors.New("cursor of another sort")
+
+// sortKey is a column task listings can be sorted by. Ties are broken by
+// the task ID so that the order, and with it the cursor, is stable.
+type sortKey struct {
+	column   string
+	nullable bool                            // NULLs come last in ascending order
+	value    func(task *models.Task) *string // nil for NULL
+	parse    func(value string) (interface{}, error)
+}
+
+// dueColumn is when a task is due: its deadline, or the start of the due
+// date of an all-day task
+const dueColumn = "COALESCE(deadline, due_date)"
+
+var taskSorts = map[string]sortKey{
+	"deadline": {
+		column:   dueColumn,
+		nullable: true,
+		value: func(task *models.Task) *string {
+			switch {
+			case task.Deadline != nil:
+				return formatCursorTime(*task.Deadline)
+			case task.DueDate != nil:
+				value := task.DueDate.String()
+				return &value
+			}
+			return nil
+		},
+		parse: parseCursorDue,
+	},
+	"created_at": {
+		column: "created_at",
+		value: func(task *models.Task) *string {
+			return formatCursorTime(task.CreatedAt)
+		},
+		parse: parseCursorTime,
+	},
+	"priority": {
+		column: "priority",
+		value: func(task *models.Task) *string {
+			value := strconv.Itoa(int(task.Priority))
+			return &value
+		},
+		parse: func(value string) (interface{}, error) {
+			return strconv.Atoi(value)
+		},
+	},
+	"title": {
+		column: "title",
+		value: func(task *models.Task) *string {
+			return &task.Title
+		},
+		parse: func(value string) (interface{}, error) {
+			return value, nil
+		},
+	},
+}
+
+func formatCursorTime(t time.Time) *string {
+	value := t.UTC().Format(time.RFC3339Nano)
+	return &value
+}
+
+func parseCursorTime(value string) (interface{}, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	return t.UTC(), err
+}
+
+// parseCursorDue reads the due time of a deadline sort cursor, which is the
+// due date itself for all-day tasks so that it compares equal to the column
+func parseCursorDue(value string) (interface{}, error) {
+	if date, err := models.ParseDate(value); err == nil {
+		return date, nil
+	}
+	return parseCursorTime(value)
+}
+
+// taskCursor points after the last task of a page. It is handed to clients
+// base64 encoded and only valid for the sort it was created with.
+type taskCursor struct {
+	Sort  string  `json:"s"`
+	Value *string `json:"v"`
+	ID    string  `json:"id"`
+}
+
+func (c taskCursor) String() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func parseTaskCursor(s string) (*taskCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var cursor taskCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// listOptions are the limit, cursor, sort and fields query parameters of task listings
+type listOptions struct {
+	limit  int
+	sort   string // e.g. "deadline" or "-priority" for descending order
+	key    sortKey
+	desc   bool
+	after  interface{}
+	cursor *taskCursor
+	fields []string // nil returns whole tasks
+}
+
+// wants reports whether the response includes the field
+func (o listOptions) wants(field string) bool {
+	if o.fields == nil {
+		return true
+	}
+	for _, f := range o.fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// parseListOptions reads the listing options of the request, sorting by
+// defaultSort unless the sort parameter names another one
+func parseListOptions(w http.ResponseWriter, r *http.Request, defaultSort string) (listOptions, bool) {
+	query := r.URL.Query()
+	opts := listOptions{limit: defaultPageSize, sort: defaultSort}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageSize {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Invalid limit",
+				"Limit must be a number from 1 to "+strconv.Itoa(maxPageSize),
+			))
+			return opts, false
+		}
+		opts.limit = n
+	}
+
+	if s := query.Get("sort"); s != "" {
+		opts.sort = s
+	}
+	key, ok := taskSorts[strings.TrimPrefix(opts.sort, "-")]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid sort",
+			"Sort must be deadline, created_at, priority or title, with a leading - for descending order",
+		))
+		return opts, false
+	}
+	opts.key = key
+	opts.desc = strings.HasPrefix(opts.sort, "-")
+
+	if c := query.Get("cursor"); c != "" {
+		cursor, err := parseTaskCursor(c)
+		if err == nil && cursor.Sort != opts.sort {
+			err = errCursorSort
+		}
+		if err == nil && cursor.Value != nil {
+			opts.after, err = key.parse(*cursor.Value)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Invalid cursor",
+				"Cursor must be the next_cursor of a previous page with the same sort",
+			))
+			return opts, false
+		}
+		opts.cursor = cursor
+	}
+
+	if f := query.Get("fields"); f != "" {
+		opts.fields = []string{"id"}
+		for _, field := range strings.Split(f, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" || field == "id" {
+				continue
+			}
+			if !taskFields[field] {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(models.NewErrorResponse(
+					"Invalid fields",
+					"Unknown field "+strconv.Quote(field)+", use "+strings.Join(taskFieldNames(), ", "),
+				))
+				return opts, false
+			}
+			opts.fields = append(opts.fields, field)
+		}
+	}
+	return opts, true
+}
+
+// listTasks returns a page of the tasks the query matches and the cursor of
+// the next page, which is empty on the last page
+func listTasks(db database.Database, query *gorm.DB, opts listOptions) ([]models.Task, string, error) {
+	col := opts.key.column
+	if opts.cursor != nil {
+		condition, args := cursorCondition(col, opts.key.nullable, opts.desc, opts.after, opts.cursor.Value == nil, opts.cursor.ID)
+		query = query.Where(condition, args...)
+	}
+
+	if opts.desc {
+		if opts.key.nullable {
+			query = query.Order(col + " IS NULL DESC")
+		}
+		query = query.Order(col + " DESC").Order("id DESC")
+	} else {
+		if opts.key.nullable {
+			query = query.Order(col + " IS NULL")
+		}
+		query = query.Order(col).Order("id")
+	}
+
+	if opts.wants("tags") {
+		query = query.Preload("Tags")
+	}
+
+	var tasks []models.Task
+	if err := query.Limit(opts.limit + 1).Find(&tasks).Error; err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(tasks) > opts.limit {
+		tasks = tasks[:opts.limit]
+		last := &tasks[len(tasks)-1]
+		next = taskCursor{Sort: opts.sort, Value: opts.key.value(last), ID: last.ID}.String()
+	}
+
+	if opts.wants("progress") {
+		if err := withProgress(db, tasks); err != nil {
+			return nil, "", err
+		}
+	}
+	return tasks, next, nil
+}
+
+// cursorCondition matches the tasks after the cursor in the sort order.
+// Nullable columns sort NULLs last in ascending and first in descending order.
+func cursorCondition(col string, nullable, desc bool, after interface{}, null bool, id string) (string, []interface{}) {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+
+	switch {
+	case !nullable:
+		return "(" + col + " " + op + " ? OR (" + col + " = ? AND id " + op + " ?))", []interface{}{after, after, id}
+	case null && !desc:
+		return "(" + col + " IS NULL AND id > ?)", []interface{}{id}
+	case null && desc:
+		return "((" + col + " IS NULL AND id < ?) OR " + col + " IS NOT NULL)", []interface{}{id}
+	case !desc:
+		return "(" + col + " > ? OR (" + col + " = ? AND id > ?) OR " + col + " IS NULL)", []interface{}{after, after, id}
+	default:
+		return "(" + col + " < ? OR (" + col + " = ? AND id < ?))", []interface{}{after, after, id}
+	}
+}
+
+// taskFields are the JSON fields of tasks that fields= can select
+var taskFields = jsonFields(reflect.TypeOf(models.Task{}))
+
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+func taskFieldNames() []string {
+	names := make([]string, 0, len(taskFields))
+	for name := range taskFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeTaskList writes a page of tasks with only the selected fields
+func writeTaskList(w http.ResponseWriter, tasks []models.Task, next string, opts listOptions) {
+	w.Header().Set("Content-Type", "application/json")
+	if opts.fields == nil {
+		json.NewEncoder(w).Encode(TaskResponse{Tasks: tasks, NextCursor: next})
+		return
+	}
+
+	projected := make([]map[string]json.RawMessage, len(tasks))
+	for i := range tasks {
+		data, err := json.Marshal(&tasks[i])
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Internal server error",
+				"Failed to encode tasks",
+			))
+			return
+		}
+
+		var all map[string]json.RawMessage
+		json.Unmarshal(data, &all)
+		projected[i] = make(map[string]json.RawMessage, len(opts.fields))
+		for _, field := range opts.fields {
+			if value, ok := all[field]; ok {
+				projected[i][field] = value
+			}
+		}
+	}
+	json.NewEncoder(w).Encode(struct {
+		Tasks      []map[string]json.RawMessage `json:"tasks"`
+		NextCursor string                       `json:"next_cursor,omitempty"`
+	}{projected, next})
+}
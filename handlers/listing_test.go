@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskPagination(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	user := models.User{Email: "pages@example.com", Password: "hash"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	tasks := []models.Task{
+		{ID: "a", Title: "Laundry", Deadline: timePtr(base.Add(48 * time.Hour)), Priority: models.PriorityLow},
+		{ID: "b", Title: "Groceries", Priority: models.PriorityHigh},
+		{ID: "c", Title: "Taxes", Deadline: timePtr(base), Priority: models.PriorityUrgent},
+		{ID: "d", Title: "Dentist", Deadline: timePtr(base), Priority: models.PriorityHigh},
+		{ID: "e", Title: "Books", Priority: models.PriorityLow},
+	}
+	for i, task := range tasks {
+		task.UserID = user.ID
+		task.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		sort     string
+		expected string
+	}{
+		{"", "a,b,c,d,e"},
+		{"-created_at", "e,d,c,b,a"},
+		{"deadline", "c,d,a,b,e"},
+		{"-deadline", "e,b,a,d,c"},
+		{"-priority", "c,d,b,e,a"},
+		{"title", "e,d,b,a,c"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Sort "+tt.sort, func(t *testing.T) {
+			var ids []string
+			cursor := ""
+			for page := 0; page < 5; page++ {
+				params := url.Values{"limit": {"2"}, "sort": {tt.sort}, "cursor": {cursor}}
+				rr := zonedRequest(t, GetTasks, "/v1/tasks?"+params.Encode(), user.ID, "")
+				if rr.Code != http.StatusOK {
+					t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+				}
+				var response TaskResponse
+				if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+					t.Fatal(err)
+				}
+				for _, task := range response.Tasks {
+					ids = append(ids, task.ID)
+				}
+				if cursor = response.NextCursor; cursor == "" {
+					break
+				}
+			}
+			if got := strings.Join(ids, ","); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDeadlineSortWithAllDayTasks(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	user := models.User{Email: "due@example.com", Password: "hash"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	day := models.Date{Year: 2026, Month: time.May, Day: 4}
+	overdue := models.Date{Year: 2026, Month: time.May, Day: 1}
+	tasks := []models.Task{
+		{ID: "week", Title: "Timed next week", Deadline: timePtr(day.In(time.UTC).AddDate(0, 0, 7))},
+		{ID: "late", Title: "All-day overdue", AllDay: true, DueDate: &overdue},
+		{ID: "morning", Title: "Timed on the day", Deadline: timePtr(day.In(time.UTC).Add(9 * time.Hour))},
+		{ID: "day1", Title: "All-day on the day", AllDay: true, DueDate: &day},
+		{ID: "day2", Title: "Another all-day on the day", AllDay: true, DueDate: &day},
+		{ID: "someday", Title: "Undated"},
+	}
+	for _, task := range tasks {
+		task.UserID = user.ID
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		sort     string
+		limit    string
+		expected string
+	}{
+		{"deadline", "1", "late,day1,day2,morning,week,someday"},
+		{"deadline", "4", "late,day1,day2,morning,week,someday"},
+		{"-deadline", "1", "someday,week,morning,day2,day1,late"},
+	}
+	for _, tt := range tests {
+		t.Run("Sort "+tt.sort+" by "+tt.limit, func(t *testing.T) {
+			var ids []string
+			cursor := ""
+			for page := 0; page < 10; page++ {
+				params := url.Values{"limit": {tt.limit}, "sort": {tt.sort}, "cursor": {cursor}}
+				rr := zonedRequest(t, GetTasks, "/v1/tasks?"+params.Encode(), user.ID, "")
+				if rr.Code != http.StatusOK {
+					t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+				}
+				var response TaskResponse
+				if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+					t.Fatal(err)
+				}
+				for _, task := range response.Tasks {
+					ids = append(ids, task.ID)
+				}
+				if cursor = response.NextCursor; cursor == "" {
+					break
+				}
+			}
+			if got := strings.Join(ids, ","); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTaskListingOptions(t *testing.T) {
+	setupTest(t)
+
+	cursor := taskCursor{Sort: "title", Value: nil, ID: "1"}.String()
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+	}{
+		{"Limit Too Large", "/v1/tasks?limit=1000", http.StatusBadRequest},
+		{"Limit Not A Number", "/v1/tasks?limit=ten", http.StatusBadRequest},
+		{"Unknown Sort", "/v1/tasks?sort=color", http.StatusBadRequest},
+		{"Garbled Cursor", "/v1/tasks?cursor=%21%21", http.StatusBadRequest},
+		{"Cursor Of Another Sort", "/v1/tasks?sort=deadline&cursor=" + cursor, http.StatusBadRequest},
+		{"Unknown Field", "/v1/tasks?fields=title,secret", http.StatusBadRequest},
+		{"Today With Options", "/v1/tasks/today?limit=10&sort=-priority&fields=title", http.StatusOK},
+		{"Backlog With Options", "/v1/tasks/backlog?limit=10&sort=title&fields=title", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := GetTasks
+			switch {
+			case strings.HasPrefix(tt.path, "/v1/tasks/today"):
+				handler = GetTodayTasks
+			case strings.HasPrefix(tt.path, "/v1/tasks/backlog"):
+				handler = GetBacklogTasks
+			}
+			rr := handlerRequest(t, handler, http.MethodGet, tt.path, nil)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", rr.Code, tt.expectedCode, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestTaskFieldSelection(t *testing.T) {
+	setupTest(t)
+
+	rr := handlerRequest(t, GetTasks, http.MethodGet, "/v1/tasks?fields=title,deadline", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var response struct {
+		Tasks []map[string]json.RawMessage `json:"tasks"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Tasks) == 0 {
+		t.Fatal("expected the tasks of the mock database")
+	}
+	for _, task := range response.Tasks {
+		if len(task) != 3 || task["id"] == nil || task["title"] == nil || task["deadline"] == nil {
+			t.Errorf("expected only id, title and deadline, got %v", task)
+		}
+	}
+}
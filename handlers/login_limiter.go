@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"just-do-it-api/auth"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// loginLimiter overrides the database backed limiter, tests use it to inject a store and clock
+var loginLimiter *auth.LoginLimiter
+
+func currentLoginLimiter() *auth.LoginLimiter {
+	if loginLimiter != nil {
+		return loginLimiter
+	}
+	return auth.NewLoginLimiter(auth.NewDBAttemptStore(db))
+}
+
+// checkLoginAllowed writes a 423 or 429 response and returns false while logins for email from this client are refused
+func checkLoginAllowed(w http.ResponseWriter, r *http.Request, limiter *auth.LoginLimiter, email string) bool {
+	decision, err := limiter.Check(email, middleware.ClientIP(r))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Failed to check login attempts",
+		))
+		return false
+	}
+
+	if decision.Allowed() {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+
+	if decision.AccountLocked {
+		w.WriteHeader(http.StatusLocked)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Account locked",
+			fmt.Sprintf("Too many failed login attempts, try again in %d seconds or reset your password", retryAfter),
+		))
+		return false
+	}
+
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(models.NewErrorResponse(
+		"Too many login attempts",
+		fmt.Sprintf("Try again in %d seconds", retryAfter),
+	))
+	return false
+}
+
+// recordLoginFailure counts a failed login and audits lockouts it caused. userID is nil for unknown emails.
+func recordLoginFailure(r *http.Request, limiter *auth.LoginLimiter, email string, userID *uint) {
+	ip := middleware.ClientIP(r)
+
+	result, err := limiter.RecordFailure(email, ip)
+	if err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+		return
+	}
+
+	if result.AccountLocked {
+		recordAuditEvent(r, userID, models.AuditLoginLocked, "Account "+email+" locked after too many failed logins")
+	}
+	if result.IPBlocked {
+		recordAuditEvent(r, userID, models.AuditLoginIPBlocked, "Address "+ip+" blocked after too many failed logins")
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"just-do-it-api/auth"
+	"just-do-it-api/mailer"
+	"just-do-it-api/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLoginLockout(t *testing.T) {
+	db = NewAuthMockDB()
+	outbox := &mailer.OutboxMailer{Dir: t.TempDir()}
+	mail = outbox
+
+	now := time.Now()
+	loginLimiter = &auth.LoginLimiter{
+		Store: auth.NewMemoryAttemptStore(),
+		Policy: auth.LockoutPolicy{
+			FreeAttempts:     1,
+			BaseDelay:        time.Second,
+			MaxDelay:         time.Second,
+			LockoutThreshold: 3,
+			LockoutDuration:  15 * time.Minute,
+			IPThreshold:      100,
+			IPBlockDuration:  time.Hour,
+			FailureWindow:    time.Hour,
+		},
+		Now: func() time.Time { return now },
+	}
+	defer func() { loginLimiter = nil }()
+
+	loginTestUser(t, "test@example.com")
+
+	login := func(password string) int {
+		w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "test@example.com", Password: password})
+		return w.Code
+	}
+
+	if code := login("wrongpassword"); code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, code)
+	}
+
+	// Even the right password is refused while throttled
+	if code := login("password123"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, code)
+	}
+
+	now = now.Add(time.Second)
+	login("wrongpassword")
+	now = now.Add(time.Second)
+	login("wrongpassword")
+
+	if code := login("password123"); code != http.StatusLocked {
+		t.Fatalf("expected status %d, got %d", http.StatusLocked, code)
+	}
+
+	var events []models.AuditEvent
+	db.Where("action = ?", models.AuditLoginLocked).Find(&events)
+	if len(events) != 1 || events[0].UserID == nil {
+		t.Fatalf("expected one lockout audit event for the user, got %d", len(events))
+	}
+
+	// A password reset unlocks the account
+	handlerRequest(t, ForgotPassword, http.MethodPost, "/api/auth/forgot-password", models.ForgotPasswordRequest{Email: "test@example.com"})
+	token := lastMailToken(t, outbox)
+	handlerRequest(t, ResetPassword, http.MethodPost, "/api/auth/reset-password", models.ResetPasswordRequest{Token: token, Password: "newpassword"})
+
+	if code := login("newpassword"); code != http.StatusOK {
+		t.Errorf("expected login to work after reset, got %d", code)
+	}
+
+	db.Where("action = ?", models.AuditLoginUnlocked).Find(&events)
+	if len(events) != 1 {
+		t.Errorf("expected one unlock audit event, got %d", len(events))
+	}
+}
@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"just-do-it-api/auth"
+	"just-do-it-api/config"
+	"just-do-it-api/jobs"
+	"just-do-it-api/mailer"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"log"
+	"net/http"
+	"time"
+)
+
+var (
+	emailChangeTTL = config.Duration("EMAIL_CHANGE_TTL", 24*time.Hour)
+
+	// accountDeletionGrace is how long a deleted account can still be restored. Zero deletes right away.
+	accountDeletionGrace = config.Duration("ACCOUNT_DELETION_GRACE", 30*24*time.Hour)
+)
+
+// currentUser loads the authenticated user
+func currentUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	var user models.User
+	if err := db.First(&user, middleware.GetUserID(r)).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"User not found",
+		))
+		return nil, false
+	}
+	return &user, true
+}
+
+// checkCurrentPassword guards sensitive account changes
+func checkCurrentPassword(w http.ResponseWriter, user *models.User, password, errorTitle string) bool {
+	if user.CheckPassword(password) != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			errorTitle,
+			"Current password is incorrect",
+		))
+		return false
+	}
+	return true
+}
+
+func GetMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+func UpdateMe(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	if req.Timezone != nil {
+		user.Timezone = *req.Timezone
+	}
+
+	if err := db.Save(user).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to update profile",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// ChangePassword sets a new password and logs out every other session
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok || !checkCurrentPassword(w, user, req.CurrentPassword, "Password change failed") {
+		return
+	}
+
+	user.Password = req.NewPassword
+	if err := user.HashPassword(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Password change failed",
+			"Failed to hash password",
+		))
+		return
+	}
+
+	if err := db.Save(user).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Password change failed",
+			"Failed to update password",
+		))
+		return
+	}
+
+	if err := auth.RevokeUserSessions(db, user.ID, middleware.GetSessionID(r)); err != nil {
+		log.Printf("Failed to revoke sessions of user %d after password change: %v", user.ID, err)
+	}
+	recordAuditEvent(r, &user.ID, models.AuditPasswordChanged, "")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangeEmail remembers the new address and mails it a confirmation link.
+// The address only changes once ConfirmEmailChange receives the token.
+func ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok || !checkCurrentPassword(w, user, req.Password, "Email change failed") {
+		return
+	}
+
+	var existingUser models.User
+	if req.Email == user.Email || db.Where("email = ?", req.Email).First(&existingUser).Error == nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Email change failed",
+			"Email already registered",
+		))
+		return
+	}
+
+	// Links sent for an earlier address must not confirm this one
+	if err := auth.RevokeActionTokens(db, user.ID, auth.PurposeChangeEmail); err != nil {
+		log.Printf("Failed to revoke email change tokens of user %d: %v", user.ID, err)
+	}
+
+	user.PendingEmail = req.Email
+	if err := db.Save(user).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Email change failed",
+			"Failed to update user",
+		))
+		return
+	}
+
+	token, err := auth.GenerateActionToken(db, user.ID, auth.PurposeChangeEmail, emailChangeTTL)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Email change failed",
+			"Failed to generate token",
+		))
+		return
+	}
+
+	err = mail.Send(r.Context(), mailer.Message{
+		To:      req.Email,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"Confirm that you want to use this address for your Just Do It account by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+			actionLink("/confirm-email", token),
+			emailChangeTTL,
+		),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Email change failed",
+			"Failed to send confirmation email",
+		))
+		return
+	}
+
+	// Warn the old address in case someone else is changing it
+	err = mail.Send(r.Context(), mailer.Message{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf(
+			"Someone asked to change the email address of your Just Do It account to %s.\n\nIf this wasn't you, reset your password right away.\n",
+			req.Email,
+		),
+	})
+	if err != nil {
+		log.Printf("Failed to send email change notice to user %d: %v", user.ID, err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.ConfirmEmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	userID, err := auth.ConsumeActionToken(db, req.Token, auth.PurposeChangeEmail)
+	if errors.Is(err, auth.ErrInvalidActionToken) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Email change failed",
+			"Invalid, expired or already used token",
+		))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Email change failed",
+			"Failed to verify token",
+		))
+		return
+	}
+
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil || user.PendingEmail == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Email change failed",
+			"No email change is pending",
+		))
+		return
+	}
+
+	// Someone may have registered the address since the change was requested
+	var existingUser models.User
+	if db.Where("email = ?", user.PendingEmail).First(&existingUser).Error == nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Email change failed",
+			"Email already registered",
+		))
+		return
+	}
+
+	previous := user.Email
+	now := time.Now()
+	user.Email = user.PendingEmail
+	user.PendingEmail = ""
+	user.EmailVerifiedAt = &now
+	if err := db.Save(&user).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Email change failed",
+			"Failed to update email",
+		))
+		return
+	}
+
+	recordAuditEvent(r, &user.ID, models.AuditEmailChanged, fmt.Sprintf("%s -> %s", previous, user.Email))
+
+	json.NewEncoder(w).Encode(user)
+}
+
+// DeleteMe schedules the account for deletion after the grace period. The
+// user can still log in and cancel until then; afterwards the purge job
+// removes the account and its tasks for good.
+func DeleteMe(w http.ResponseWriter, r *http.Request) {
+	var req models.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok || !checkCurrentPassword(w, user, req.Password, "Account deletion failed") {
+		return
+	}
+
+	now := time.Now()
+	if user.DeletionScheduledAt == nil {
+		scheduledAt := now.Add(accountDeletionGrace)
+		user.DeletionScheduledAt = &scheduledAt
+		if err := db.Save(user).Error; err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Account deletion failed",
+				"Failed to update user",
+			))
+			return
+		}
+		recordAuditEvent(r, &user.ID, models.AuditDeletionScheduled, scheduledAt.Format(time.RFC3339))
+	}
+
+	if accountDeletionGrace <= 0 {
+		if _, err := jobs.PurgeAccount(db, user.ID, now); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Account deletion failed",
+				"Failed to delete account",
+			))
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := auth.RevokeUserSessions(db, user.ID, middleware.GetSessionID(r)); err != nil {
+		log.Printf("Failed to revoke sessions of user %d after deletion request: %v", user.ID, err)
+	}
+
+	err := mail.Send(r.Context(), mailer.Message{
+		To:      user.Email,
+		Subject: "Your account will be deleted",
+		Body: fmt.Sprintf(
+			"Your Just Do It account and all of its tasks will be deleted on %s.\n\nChanged your mind? Log in before then and cancel the deletion.\n",
+			user.DeletionScheduledAt.UTC().Format(time.RFC1123),
+		),
+	})
+	if err != nil {
+		log.Printf("Failed to send deletion notice to user %d: %v", user.ID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(user)
+}
+
+func CancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if user.DeletionScheduledAt == nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not scheduled",
+			"The account is not scheduled for deletion",
+		))
+		return
+	}
+
+	user.DeletionScheduledAt = nil
+	if err := db.Save(user).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to update user",
+		))
+		return
+	}
+	recordAuditEvent(r, &user.ID, models.AuditDeletionCanceled, "")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
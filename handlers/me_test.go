@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"just-do-it-api/jobs"
+	"just-do-it-api/mailer"
+	"just-do-it-api/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// meRequest builds a request as sent with the current session of the logged in user
+func meRequest(t *testing.T, method, path string, login models.AuthResponse, payload interface{}) *http.Request {
+	var session models.Session
+	if err := db.Where("user_id = ? AND revoked_at IS NULL", login.User.ID).Order("created_at DESC").First(&session).Error; err != nil {
+		t.Fatalf("no active session: %v", err)
+	}
+
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	return withSession(httptest.NewRequest(method, path, &body), login.User.ID, session.ID)
+}
+
+func TestUpdateMe(t *testing.T) {
+	db = NewAuthMockDB()
+	login := loginTestUser(t, "test@example.com")
+
+	name := "Test User"
+	w := httptest.NewRecorder()
+	UpdateMe(w, meRequest(t, http.MethodPatch, "/v1/me", login, models.UpdateProfileRequest{Name: &name}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	GetMe(w, meRequest(t, http.MethodGet, "/v1/me", login, nil))
+	var user models.User
+	json.NewDecoder(w.Body).Decode(&user)
+	if user.Name != name || user.Email != "test@example.com" {
+		t.Errorf("unexpected profile %q <%s>", user.Name, user.Email)
+	}
+}
+
+func TestUpdateMeTimezone(t *testing.T) {
+	db = NewAuthMockDB()
+	login := loginTestUser(t, "test@example.com")
+
+	tests := []struct {
+		name           string
+		timezone       string
+		expectedStatus int
+	}{
+		{"Valid Time Zone", "America/Sao_Paulo", http.StatusOK},
+		{"Unknown Time Zone", "America/Atlantis", http.StatusBadRequest},
+		{"Server Time Zone", "Local", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			UpdateMe(w, meRequest(t, http.MethodPatch, "/v1/me", login, models.UpdateProfileRequest{Timezone: &tt.timezone}))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	var user models.User
+	db.First(&user, login.User.ID)
+	if user.Timezone != "America/Sao_Paulo" {
+		t.Errorf("expected the time zone to be America/Sao_Paulo, got %q", user.Timezone)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	db = NewAuthMockDB()
+	other := loginTestUser(t, "test@example.com")
+	current := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "test@example.com", Password: "password123"})
+	var login models.AuthResponse
+	json.NewDecoder(current.Body).Decode(&login)
+
+	tests := []struct {
+		name           string
+		payload        models.ChangePasswordRequest
+		expectedStatus int
+	}{
+		{"Wrong Current Password", models.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "newpassword"}, http.StatusUnauthorized},
+		{"Too Short", models.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "new"}, http.StatusBadRequest},
+		{"Success", models.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "newpassword"}, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ChangePassword(w, meRequest(t, http.MethodPost, "/v1/me/password", login, tt.payload))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	// The other session is logged out, the current one stays
+	if w := refresh(other.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the other session to be revoked, got %d", w.Code)
+	}
+	if w := refresh(login.RefreshToken); w.Code != http.StatusOK {
+		t.Errorf("expected the current session to stay, got %d", w.Code)
+	}
+	if w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "test@example.com", Password: "newpassword"}); w.Code != http.StatusOK {
+		t.Errorf("expected the new password to work, got %d", w.Code)
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	db = NewAuthMockDB()
+	outbox := &mailer.OutboxMailer{Dir: t.TempDir()}
+	mail = outbox
+
+	login := loginTestUser(t, "test@example.com")
+	loginTestUser(t, "taken@example.com")
+
+	w := httptest.NewRecorder()
+	ChangeEmail(w, meRequest(t, http.MethodPost, "/v1/me/email", login, models.ChangeEmailRequest{Email: "taken@example.com", Password: "password123"}))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a registered address, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	ChangeEmail(w, meRequest(t, http.MethodPost, "/v1/me/email", login, models.ChangeEmailRequest{Email: "first@example.com", Password: "password123"}))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", w.Code)
+	}
+	messages, _ := outbox.Messages()
+	firstToken := lastMailTokenTo(t, messages, "first@example.com")
+
+	// A newer request supersedes the first link
+	w = httptest.NewRecorder()
+	ChangeEmail(w, meRequest(t, http.MethodPost, "/v1/me/email", login, models.ChangeEmailRequest{Email: "new@example.com", Password: "password123"}))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", w.Code)
+	}
+	messages, _ = outbox.Messages()
+	token := lastMailTokenTo(t, messages, "new@example.com")
+
+	if w := handlerRequest(t, ConfirmEmailChange, http.MethodPost, "/v1/me/email/confirm", models.ConfirmEmailChangeRequest{Token: firstToken}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected the superseded link to fail, got %d", w.Code)
+	}
+
+	// The address only changes once confirmed
+	var user models.User
+	db.First(&user, login.User.ID)
+	if user.Email != "test@example.com" || user.PendingEmail != "new@example.com" {
+		t.Errorf("expected pending change to new@example.com, got %s (pending %s)", user.Email, user.PendingEmail)
+	}
+
+	w = handlerRequest(t, ConfirmEmailChange, http.MethodPost, "/v1/me/email/confirm", models.ConfirmEmailChangeRequest{Token: token})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var changed models.User
+	json.NewDecoder(w.Body).Decode(&changed)
+	if changed.Email != "new@example.com" || changed.PendingEmail != "" || !changed.EmailVerified() {
+		t.Errorf("expected verified new@example.com, got %+v", changed)
+	}
+
+	if w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "new@example.com", Password: "password123"}); w.Code != http.StatusOK {
+		t.Errorf("expected login with the new address to work, got %d", w.Code)
+	}
+}
+
+// lastMailTokenTo returns the token of the most recent email sent to the address
+func lastMailTokenTo(t *testing.T, messages []mailer.Message, to string) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].To == to {
+			if match := tokenPattern.FindStringSubmatch(messages[i].Body); match != nil {
+				return match[1]
+			}
+		}
+	}
+	t.Fatalf("expected an email with a link to %s", to)
+	return ""
+}
+
+func TestDeleteMe(t *testing.T) {
+	db = NewAuthMockDB()
+	mail = &mailer.OutboxMailer{Dir: t.TempDir()}
+
+	login := loginTestUser(t, "test@example.com")
+	task := models.Task{ID: "1", UserID: login.User.ID, Title: "Task", Deadline: timePtr(time.Now())}
+	db.Create(&task)
+	db.Create(&models.TaskEvent{TaskID: "1", UserID: login.User.ID, ActorID: login.User.ID, Action: models.TaskCreated})
+	db.Delete(&models.Task{}, "id = ?", "1") // soft deleted tasks are purged too
+
+	w := httptest.NewRecorder()
+	DeleteMe(w, meRequest(t, http.MethodDelete, "/v1/me", login, models.DeleteAccountRequest{Password: "wrong"}))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for a wrong password, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	DeleteMe(w, meRequest(t, http.MethodDelete, "/v1/me", login, models.DeleteAccountRequest{Password: "password123"}))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", w.Code)
+	}
+	var user models.User
+	json.NewDecoder(w.Body).Decode(&user)
+	if user.DeletionScheduledAt == nil || user.DeletionScheduledAt.Before(time.Now().Add(accountDeletionGrace-time.Minute)) {
+		t.Fatalf("expected deletion to be scheduled after the grace period, got %v", user.DeletionScheduledAt)
+	}
+
+	// Within the grace period nothing is purged and the deletion can be canceled
+	if n, _ := jobs.PurgeDeletedAccounts(context.Background(), db, time.Now()); n != 0 {
+		t.Errorf("expected nothing to be purged yet, got %d", n)
+	}
+
+	w = httptest.NewRecorder()
+	CancelAccountDeletion(w, meRequest(t, http.MethodPost, "/v1/me/cancel-deletion", login, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if n, _ := jobs.PurgeDeletedAccounts(context.Background(), db, time.Now().Add(2*accountDeletionGrace)); n != 0 {
+		t.Errorf("expected a canceled deletion not to be purged, got %d", n)
+	}
+
+	// Once the grace period is over the account and its tasks are gone for good
+	w = httptest.NewRecorder()
+	DeleteMe(w, meRequest(t, http.MethodDelete, "/v1/me", login, models.DeleteAccountRequest{Password: "password123"}))
+	if n, err := jobs.PurgeDeletedAccounts(context.Background(), db, time.Now().Add(accountDeletionGrace+time.Minute)); err != nil || n != 1 {
+		t.Fatalf("expected 1 purged account, got %d (%v)", n, err)
+	}
+
+	var users, tasks, events int64
+	db.Unscoped().Model(&models.User{}).Where("id = ?", login.User.ID).Count(&users)
+	db.Unscoped().Model(&models.Task{}).Where("user_id = ?", login.User.ID).Count(&tasks)
+	db.Unscoped().Model(&models.TaskEvent{}).Where("user_id = ?", login.User.ID).Count(&events)
+	if users != 0 || tasks != 0 || events != 0 {
+		t.Errorf("expected user, tasks and history to be purged, found %d users, %d tasks and %d events", users, tasks, events)
+	}
+}
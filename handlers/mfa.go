@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/auth"
+	"just-do-it-api/config"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"time"
+)
+
+const recoveryCodeCount = 10
+
+// mfaChallengeTTL is how long the user has to enter the second factor after the password
+var mfaChallengeTTL = config.Duration("MFA_CHALLENGE_TTL", 5*time.Minute)
+
+// checkSecondFactor verifies a TOTP code or, if no code is given, a recovery
+// code. Accepted codes are recorded so they can't be used a second time.
+func checkSecondFactor(user *models.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+		if !ok {
+			return false, nil
+		}
+
+		result := db.Where("id = ? AND totp_last_step < ?", user.ID, step).Model(&models.User{}).Update("totp_last_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		user.TOTPLastStep = step
+		return result.RowsAffected == 1, nil
+	}
+
+	if recoveryCode == "" {
+		return false, nil
+	}
+
+	codeHash := auth.HashToken(auth.NormalizeRecoveryCode(recoveryCode))
+	result := db.Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, codeHash).Model(&models.RecoveryCode{}).Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// replaceRecoveryCodes invalidates the user's recovery codes and returns a new set
+func replaceRecoveryCodes(userID uint) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	for _, code := range codes {
+		record := models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(code)),
+		}
+		if err := db.Create(&record).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return codes, nil
+}
+
+// LoginMFA is the second login step for users with 2FA enabled. It exchanges
+// the challenge token from Login and a TOTP or recovery code for a session.
+func LoginMFA(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.LoginMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	userID, err := auth.PeekActionToken(db, req.MFAToken, auth.PurposeMFAChallenge)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Invalid or expired MFA token",
+		))
+		return
+	}
+
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil || !user.TOTPEnabled {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Invalid or expired MFA token",
+		))
+		return
+	}
+
+	// Guessing codes counts against the same limits as guessing passwords
+	limiter := currentLoginLimiter()
+	if !checkLoginAllowed(w, r, limiter, user.Email) {
+		return
+	}
+
+	ok, err := checkSecondFactor(&user, req.Code, req.RecoveryCode)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Failed to verify code",
+		))
+		return
+	}
+	if !ok {
+		recordLoginFailure(r, limiter, user.Email, &user.ID)
+
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Invalid authentication code",
+		))
+		return
+	}
+
+	if !checkAccountActive(w, &user, "Login failed") {
+		return
+	}
+
+	// The challenge is only used up once the second factor succeeded, so a typo doesn't restart the login
+	if _, err := auth.ConsumeActionToken(db, req.MFAToken, auth.PurposeMFAChallenge); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Invalid or expired MFA token",
+		))
+		return
+	}
+
+	response, err := newAuthResponse(r, &user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Failed to generate token",
+		))
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// EnrollTOTP creates a new TOTP secret for the user. 2FA is only enabled once ConfirmTOTP receives a valid code.
+func EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	if err := db.First(&user, middleware.GetUserID(r)).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"User not found",
+		))
+		return
+	}
+
+	if user.TOTPEnabled {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Enrollment failed",
+			"Two-factor authentication is already enabled",
+		))
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Enrollment failed",
+			"Failed to generate secret",
+		))
+		return
+	}
+
+	user.TOTPSecret = secret
+	user.TOTPLastStep = 0
+	if err := db.Save(&user).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Enrollment failed",
+			"Failed to save secret",
+		))
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Enrollment failed",
+			"Failed to generate recovery codes",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.TOTPEnrollResponse{
+		Secret:        secret,
+		OTPAuthURI:    auth.TOTPURI(secret, user.Email),
+		RecoveryCodes: codes,
+	})
+}
+
+func ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var req models.TOTPConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	var user models.User
+	if err := db.First(&user, middleware.GetUserID(r)).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"User not found",
+		))
+		return
+	}
+
+	if user.TOTPEnabled {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Confirmation failed",
+			"Two-factor authentication is already enabled",
+		))
+		return
+	}
+
+	if user.TOTPSecret == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Confirmation failed",
+			"Start the enrollment first",
+		))
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, req.Code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Confirmation failed",
+			"Invalid authentication code",
+		))
+		return
+	}
+
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+	if err := db.Save(&user).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Confirmation failed",
+			"Failed to enable two-factor authentication",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	var req models.TOTPDisableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	var user models.User
+	if err := db.First(&user, middleware.GetUserID(r)).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"User not found",
+		))
+		return
+	}
+
+	if !user.TOTPEnabled {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Disable failed",
+			"Two-factor authentication is not enabled",
+		))
+		return
+	}
+
+	if err := user.CheckPassword(req.Password); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Disable failed",
+			"Invalid password",
+		))
+		return
+	}
+
+	if !respondSecondFactor(w, &user, req.Code, req.RecoveryCode, "Disable failed") {
+		return
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	if err := db.Save(&user).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Disable failed",
+			"Failed to disable two-factor authentication",
+		))
+		return
+	}
+
+	if err := db.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Disable failed",
+			"Failed to delete recovery codes",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes. It requires a current TOTP code.
+func RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req models.TOTPConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	var user models.User
+	if err := db.First(&user, middleware.GetUserID(r)).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"User not found",
+		))
+		return
+	}
+
+	if !user.TOTPEnabled {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Regeneration failed",
+			"Two-factor authentication is not enabled",
+		))
+		return
+	}
+
+	if !respondSecondFactor(w, &user, req.Code, "", "Regeneration failed") {
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Regeneration failed",
+			"Failed to generate recovery codes",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// respondSecondFactor checks the second factor and writes the error response when it fails
+func respondSecondFactor(w http.ResponseWriter, user *models.User, code, recoveryCode, errorTitle string) bool {
+	ok, err := checkSecondFactor(user, code, recoveryCode)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			errorTitle,
+			"Failed to verify code",
+		))
+		return false
+	}
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			errorTitle,
+			"Invalid authentication code",
+		))
+		return false
+	}
+	return true
+}
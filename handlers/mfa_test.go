@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"just-do-it-api/auth"
+	"just-do-it-api/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// enableTOTP enrolls and confirms 2FA for the logged in user and returns the secret and recovery codes
+func enableTOTP(t *testing.T, login models.AuthResponse) models.TOTPEnrollResponse {
+	var session models.Session
+	db.Where("user_id = ?", login.User.ID).First(&session)
+
+	req := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/2fa/enroll", nil), login.User.ID, session.ID)
+	w := httptest.NewRecorder()
+	EnrollTOTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("enroll failed with status %d", w.Code)
+	}
+
+	var enrollment models.TOTPEnrollResponse
+	json.NewDecoder(w.Body).Decode(&enrollment)
+	if !strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/") {
+		t.Errorf("unexpected otpauth URI %q", enrollment.OTPAuthURI)
+	}
+	if len(enrollment.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("expected %d recovery codes, got %d", recoveryCodeCount, len(enrollment.RecoveryCodes))
+	}
+
+	code, _ := auth.TOTPCode(enrollment.Secret, time.Now())
+	payloadBytes, _ := json.Marshal(models.TOTPConfirmRequest{Code: code})
+	req = withSession(httptest.NewRequest(http.MethodPost, "/api/auth/2fa/confirm", bytes.NewReader(payloadBytes)), login.User.ID, session.ID)
+	w = httptest.NewRecorder()
+	ConfirmTOTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("confirm failed with status %d", w.Code)
+	}
+
+	return enrollment
+}
+
+func mfaChallenge(t *testing.T) string {
+	w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "test@example.com", Password: "password123"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login failed with status %d", w.Code)
+	}
+
+	var challenge models.MFAChallengeResponse
+	json.NewDecoder(w.Body).Decode(&challenge)
+	if !challenge.MFARequired || challenge.MFAToken == "" {
+		t.Fatal("expected an MFA challenge")
+	}
+	return challenge.MFAToken
+}
+
+func TestLoginMFA(t *testing.T) {
+	db = NewAuthMockDB()
+
+	login := loginTestUser(t, "test@example.com")
+	enrollment := enableTOTP(t, login)
+
+	// The code used for confirmation can't be replayed, the next one works
+	nextCode, _ := auth.TOTPCode(enrollment.Secret, time.Now().Add(30*time.Second))
+	usedCode, _ := auth.TOTPCode(enrollment.Secret, time.Now())
+
+	challenge := mfaChallenge(t)
+
+	tests := []struct {
+		name           string
+		payload        models.LoginMFARequest
+		expectedStatus int
+	}{
+		{
+			name:           "Wrong Code",
+			payload:        models.LoginMFARequest{MFAToken: challenge, Code: "000000"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Replayed Code",
+			payload:        models.LoginMFARequest{MFAToken: challenge, Code: usedCode},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Invalid Challenge",
+			payload:        models.LoginMFARequest{MFAToken: login.Token, Code: nextCode},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Valid Code",
+			payload:        models.LoginMFARequest{MFAToken: challenge, Code: nextCode},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Challenge Already Used",
+			payload:        models.LoginMFARequest{MFAToken: challenge, RecoveryCode: enrollment.RecoveryCodes[0]},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Recovery Code",
+			payload:        models.LoginMFARequest{MFAToken: mfaChallenge(t), RecoveryCode: strings.ToUpper(enrollment.RecoveryCodes[1])},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Recovery Code Already Used",
+			payload:        models.LoginMFARequest{MFAToken: mfaChallenge(t), RecoveryCode: enrollment.RecoveryCodes[1]},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlerRequest(t, LoginMFA, http.MethodPost, "/api/auth/login/mfa", tt.payload)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.AuthResponse
+				json.NewDecoder(w.Body).Decode(&response)
+				if response.Token == "" {
+					t.Error("expected token in response")
+				}
+			}
+		})
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	db = NewAuthMockDB()
+
+	login := loginTestUser(t, "test@example.com")
+	enrollment := enableTOTP(t, login)
+
+	var session models.Session
+	db.Where("user_id = ?", login.User.ID).First(&session)
+
+	disable := func(payload models.TOTPDisableRequest) int {
+		payloadBytes, _ := json.Marshal(payload)
+		req := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/2fa/disable", bytes.NewReader(payloadBytes)), login.User.ID, session.ID)
+		w := httptest.NewRecorder()
+		DisableTOTP(w, req)
+		return w.Code
+	}
+
+	if code := disable(models.TOTPDisableRequest{Password: "wrongpassword", RecoveryCode: enrollment.RecoveryCodes[0]}); code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for wrong password, got %d", http.StatusUnauthorized, code)
+	}
+	if code := disable(models.TOTPDisableRequest{Password: "password123", RecoveryCode: enrollment.RecoveryCodes[0]}); code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, code)
+	}
+
+	// Login no longer asks for a second factor
+	w := handlerRequest(t, Login, http.MethodPost, "/api/auth/login", models.LoginRequest{Email: "test@example.com", Password: "password123"})
+	var response models.AuthResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Token == "" {
+		t.Error("expected token after disabling 2FA")
+	}
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"just-do-it-api/auth"
+	"just-do-it-api/config"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"just-do-it-api/oidc"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// oidcProviders are the configured OpenID Connect providers by name
+	oidcProviders = oidc.LoadProvidersFromEnv()
+
+	oidcStateTTL = config.Duration("OIDC_STATE_TTL", 10*time.Minute)
+)
+
+// oidcProvider returns the provider named in /api/auth/oidc/{provider}/...
+func oidcProvider(w http.ResponseWriter, r *http.Request) (*oidc.Provider, bool) {
+	name, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/auth/oidc/"), "/")
+	provider, ok := oidcProviders[name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Unknown identity provider",
+		))
+		return nil, false
+	}
+	return provider, true
+}
+
+// startOIDCFlow stores a new login state and returns the provider URL to send the user to
+func startOIDCFlow(r *http.Request, provider *oidc.Provider, linkUserID *uint) (string, error) {
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oidc.GeneratePKCE()
+	if err != nil {
+		return "", err
+	}
+
+	loginState := models.OIDCLoginState{
+		State:        state,
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}
+	if err := db.Create(&loginState).Error; err != nil {
+		return "", err
+	}
+
+	return provider.AuthCodeURL(r.Context(), state, nonce, challenge)
+}
+
+// OIDCLogin redirects the user to the provider to log in
+func OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := oidcProvider(w, r)
+	if !ok {
+		return
+	}
+
+	authURL, err := startOIDCFlow(r, provider, nil)
+	if err != nil {
+		log.Printf("Failed to start OIDC login with %s: %v", provider.Name, err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Identity provider is unavailable",
+		))
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCLink starts the flow that links another identity to the authenticated user
+func OIDCLink(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	provider, ok := oidcProvider(w, r)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserID(r)
+	authURL, err := startOIDCFlow(r, provider, &userID)
+	if err != nil {
+		log.Printf("Failed to start OIDC link with %s: %v", provider.Name, err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Link failed",
+			"Identity provider is unavailable",
+		))
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.OIDCAuthorizationResponse{AuthorizationURL: authURL})
+}
+
+// OIDCCallback completes the flow: it exchanges the authorization code, then
+// either links the identity to the user who started a link, or logs in the
+// user owning the identity, creating or linking the account by verified email.
+func OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	provider, ok := oidcProvider(w, r)
+	if !ok {
+		return
+	}
+
+	if errorCode := r.URL.Query().Get("error"); errorCode != "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			fmt.Sprintf("Identity provider returned %s", errorCode),
+		))
+		return
+	}
+
+	req := models.OIDCCallbackRequest{
+		Code:  r.URL.Query().Get("code"),
+		State: r.URL.Query().Get("state"),
+	}
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	// The state is single use: only the request that deletes it may continue
+	var loginState models.OIDCLoginState
+	if err := db.Where("state = ? AND provider = ?", req.State, provider.Name).First(&loginState).Error; err != nil ||
+		db.Delete(&models.OIDCLoginState{}, "state = ?", loginState.State).RowsAffected != 1 ||
+		time.Now().After(loginState.ExpiresAt) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Invalid or expired state",
+		))
+		return
+	}
+
+	claims, err := provider.Exchange(r.Context(), req.Code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Printf("OIDC code exchange with %s failed: %v", provider.Name, err)
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Could not verify the identity provider response",
+		))
+		return
+	}
+
+	if loginState.LinkUserID != nil {
+		linkIdentity(w, r, provider.Name, claims, *loginState.LinkUserID)
+		return
+	}
+
+	user, status, err := userForIdentity(provider.Name, claims)
+	if err != nil {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			err.Error(),
+		))
+		return
+	}
+
+	if !checkAccountActive(w, user, "Login failed") {
+		return
+	}
+
+	// Accounts with 2FA still need their second factor
+	if user.TOTPEnabled {
+		mfaToken, err := auth.GenerateActionToken(db, user.ID, auth.PurposeMFAChallenge, mfaChallengeTTL)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Login failed",
+				"Failed to generate token",
+			))
+			return
+		}
+
+		json.NewEncoder(w).Encode(models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
+		return
+	}
+
+	response, err := newAuthResponse(r, user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Login failed",
+			"Failed to generate token",
+		))
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// userForIdentity finds the user owning the identity. Unknown identities are
+// linked to the account with the same email, or get a new account, but only
+// when the provider vouches for the email address.
+func userForIdentity(providerName string, claims *oidc.IDTokenClaims) (*models.User, int, error) {
+	now := time.Now()
+
+	var identity models.UserIdentity
+	err := db.Where("provider = ? AND subject = ?", providerName, claims.Subject).First(&identity).Error
+	if err == nil {
+		var user models.User
+		if err := db.First(&user, identity.UserID).Error; err != nil {
+			return nil, http.StatusUnauthorized, errors.New("Account no longer exists")
+		}
+
+		identity.LastLoginAt = &now
+		if claims.Email != "" {
+			identity.Email = claims.Email
+		}
+		db.Save(&identity)
+		return &user, http.StatusOK, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, http.StatusInternalServerError, errors.New("Failed to look up identity")
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, http.StatusForbidden, errors.New("Identity provider did not return a verified email")
+	}
+
+	var user models.User
+	err = db.Where("email = ?", claims.Email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The account can't be used with a password until it is reset
+		password, err := oidc.RandomString(32)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.New("Failed to create user")
+		}
+
+		user = models.User{
+			Email:           claims.Email,
+			Password:        password,
+			EmailVerifiedAt: &now,
+		}
+		if err := user.HashPassword(); err != nil {
+			return nil, http.StatusInternalServerError, errors.New("Failed to create user")
+		}
+		if err := db.Create(&user).Error; err != nil {
+			return nil, http.StatusInternalServerError, errors.New("Failed to create user")
+		}
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Failed to look up user")
+	} else if !user.EmailVerified() {
+		// Whoever registered the address never proved owning it, so linking
+		// could hand the account to them. Its owner can log in and link instead.
+		return nil, http.StatusConflict, errors.New("Email belongs to an unverified account, log in with your password and link the identity there")
+	}
+
+	identity = models.UserIdentity{
+		UserID:      user.ID,
+		Provider:    providerName,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+	}
+	if err := db.Create(&identity).Error; err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Failed to link identity")
+	}
+
+	return &user, http.StatusOK, nil
+}
+
+func linkIdentity(w http.ResponseWriter, r *http.Request, providerName string, claims *oidc.IDTokenClaims, userID uint) {
+	var identity models.UserIdentity
+	if err := db.Where("provider = ? AND subject = ?", providerName, claims.Subject).First(&identity).Error; err == nil {
+		if identity.UserID != userID {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Link failed",
+				"Identity is already linked to another account",
+			))
+			return
+		}
+
+		json.NewEncoder(w).Encode(identity)
+		return
+	}
+
+	identity = models.UserIdentity{
+		UserID:   userID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+	if err := db.Create(&identity).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Link failed",
+			"Failed to link identity",
+		))
+		return
+	}
+
+	recordAuditEvent(r, &userID, models.AuditIdentityLinked, providerName)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(identity)
+}
+
+func GetIdentities(w http.ResponseWriter, r *http.Request) {
+	var identities []models.UserIdentity
+
+	userID := middleware.GetUserID(r)
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch identities",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.UserIdentitiesResponse{Identities: identities})
+}
+
+func UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	identityID := strings.TrimPrefix(r.URL.Path, "/api/auth/identities/")
+	if identityID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Identity ID is required",
+		))
+		return
+	}
+
+	var identity models.UserIdentity
+	userID := middleware.GetUserID(r)
+	if err := db.Where("id = ? AND user_id = ?", identityID, userID).First(&identity).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Identity not found",
+		))
+		return
+	}
+
+	if err := db.Delete(&identity).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to unlink identity",
+		))
+		return
+	}
+
+	recordAuditEvent(r, &userID, models.AuditIdentityUnlinked, identity.Provider)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"just-do-it-api/models"
+	"just-do-it-api/oidc"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdentity is the account the mock provider logs in with
+type mockIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type pendingCode struct {
+	identity  mockIdentity
+	nonce     string
+	challenge string
+}
+
+// mockOIDCProvider is a minimal OpenID Connect provider: discovery, JWKS and
+// a token endpoint that checks the PKCE verifier and issues RS256 ID tokens
+type mockOIDCProvider struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	audience string
+
+	mu    sync.Mutex
+	codes map[string]pendingCode
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockOIDCProvider{key: key, audience: "test-client", codes: make(map[string]pendingCode)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "mock-key",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		m.mu.Lock()
+		pending, ok := m.codes[r.PostForm.Get("code")]
+		delete(m.codes, r.PostForm.Get("code"))
+		m.mu.Unlock()
+
+		if !ok || r.PostForm.Get("client_id") != "test-client" ||
+			oidc.S256Challenge(r.PostForm.Get("code_verifier")) != pending.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            m.server.URL,
+			"aud":            m.audience,
+			"sub":            pending.identity.Subject,
+			"email":          pending.identity.Email,
+			"email_verified": pending.identity.EmailVerified,
+			"nonce":          pending.nonce,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		token.Header["kid"] = "mock-key"
+		idToken, _ := token.SignedString(key)
+
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "mock-access-token",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	oidcProviders = map[string]*oidc.Provider{
+		"mock": {
+			Name:        "mock",
+			Issuer:      m.server.URL,
+			ClientID:    "test-client",
+			RedirectURL: "http://localhost:8080/api/auth/oidc/mock/callback",
+			Scopes:      []string{"openid", "email"},
+		},
+	}
+	t.Cleanup(func() { oidcProviders = nil })
+
+	return m
+}
+
+// authorize plays the provider's login page: it accepts the authorization URL
+// and returns the code and state the user is redirected back with
+func (m *mockOIDCProvider) authorize(t *testing.T, authorizationURL string, identity mockIdentity) (string, string) {
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL lacks PKCE: %s", authorizationURL)
+	}
+
+	code, _ := oidc.RandomString(16)
+	m.mu.Lock()
+	m.codes[code] = pendingCode{identity: identity, nonce: query.Get("nonce"), challenge: query.Get("code_challenge")}
+	m.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+func oidcCallback(code, state string) *httptest.ResponseRecorder {
+	query := url.Values{"code": {code}, "state": {state}}
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/mock/callback?"+query.Encode(), nil)
+	w := httptest.NewRecorder()
+	OIDCCallback(w, req)
+	return w
+}
+
+func oidcLogin(t *testing.T, m *mockOIDCProvider, identity mockIdentity) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/mock/login", nil)
+	w := httptest.NewRecorder()
+	OIDCLogin(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected a redirect to the provider, got %d: %s", w.Code, w.Body.String())
+	}
+
+	return oidcCallback(m.authorize(t, w.Header().Get("Location"), identity))
+}
+
+func TestOIDCLogin(t *testing.T) {
+	db = NewAuthMockDB()
+	mock := newMockOIDCProvider(t)
+
+	identity := mockIdentity{Subject: "user-1", Email: "sso@example.com", EmailVerified: true}
+
+	// The first login creates a verified account
+	w := oidcLogin(t, mock, identity)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var first models.AuthResponse
+	json.NewDecoder(w.Body).Decode(&first)
+	if first.Token == "" || first.RefreshToken == "" {
+		t.Error("expected tokens in the response")
+	}
+	if first.User.Email != identity.Email || !first.User.EmailVerified() {
+		t.Errorf("expected a verified account for %s, got %+v", identity.Email, first.User)
+	}
+
+	// Later logins find the account through the identity, even with a new email
+	identity.Email = "renamed@example.com"
+	w = oidcLogin(t, mock, identity)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var second models.AuthResponse
+	json.NewDecoder(w.Body).Decode(&second)
+	if second.User.ID != first.User.ID {
+		t.Errorf("expected user %d, got %d", first.User.ID, second.User.ID)
+	}
+
+	t.Run("Unverified Email", func(t *testing.T) {
+		w := oidcLogin(t, mock, mockIdentity{Subject: "user-2", Email: "unverified@example.com"})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("State Replay", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/mock/login", nil)
+		w := httptest.NewRecorder()
+		OIDCLogin(w, req)
+		code, state := mock.authorize(t, w.Header().Get("Location"), identity)
+
+		if w := oidcCallback(code, state); w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if w := oidcCallback(code, state); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for a reused state, got %d", w.Code)
+		}
+	})
+
+	t.Run("Wrong Audience", func(t *testing.T) {
+		mock.audience = "another-client"
+		defer func() { mock.audience = "test-client" }()
+
+		if w := oidcLogin(t, mock, identity); w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("Unknown Provider", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/unknown/login", nil)
+		w := httptest.NewRecorder()
+		OIDCLogin(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestOIDCLinkByEmail(t *testing.T) {
+	db = NewAuthMockDB()
+	mock := newMockOIDCProvider(t)
+
+	login := loginTestUser(t, "test@example.com")
+	identity := mockIdentity{Subject: "user-1", Email: "test@example.com", EmailVerified: true}
+
+	// The password account never proved owning the address
+	if w := oidcLogin(t, mock, identity); w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 for an unverified account, got %d", w.Code)
+	}
+
+	now := time.Now()
+	db.Where("id = ?", login.User.ID).Model(&models.User{}).Update("email_verified_at", &now)
+
+	w := oidcLogin(t, mock, identity)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.AuthResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.User.ID != login.User.ID {
+		t.Errorf("expected the existing user %d, got %d", login.User.ID, response.User.ID)
+	}
+}
+
+func TestOIDCLinkIdentity(t *testing.T) {
+	db = NewAuthMockDB()
+	mock := newMockOIDCProvider(t)
+
+	login := loginTestUser(t, "test@example.com")
+	var session models.Session
+	db.Where("user_id = ?", login.User.ID).First(&session)
+
+	link := func(userID uint, sessionID string, identity mockIdentity) *httptest.ResponseRecorder {
+		req := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/oidc/mock/link", nil), userID, sessionID)
+		w := httptest.NewRecorder()
+		OIDCLink(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response models.OIDCAuthorizationResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return oidcCallback(mock.authorize(t, response.AuthorizationURL, identity))
+	}
+
+	// Identities with other emails, even unverified ones, can be linked
+	for _, identity := range []mockIdentity{
+		{Subject: "work", Email: "me@company.example"},
+		{Subject: "personal", Email: "me@mail.example", EmailVerified: true},
+	} {
+		if w := link(login.User.ID, session.ID, identity); w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	req := withSession(httptest.NewRequest(http.MethodGet, "/api/auth/identities", nil), login.User.ID, session.ID)
+	w := httptest.NewRecorder()
+	GetIdentities(w, req)
+	var identities models.UserIdentitiesResponse
+	json.NewDecoder(w.Body).Decode(&identities)
+	if len(identities.Identities) != 2 {
+		t.Fatalf("expected 2 identities, got %d", len(identities.Identities))
+	}
+
+	// Logging in with a linked identity reaches the same account
+	w = oidcLogin(t, mock, mockIdentity{Subject: "work", Email: "me@company.example"})
+	var response models.AuthResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.User.ID != login.User.ID {
+		t.Errorf("expected user %d, got %d", login.User.ID, response.User.ID)
+	}
+
+	// Another user can't take over a linked identity
+	other := loginTestUser(t, "other@example.com")
+	var otherSession models.Session
+	db.Where("user_id = ?", other.User.ID).First(&otherSession)
+	if w := link(other.User.ID, otherSession.ID, mockIdentity{Subject: "work"}); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", w.Code)
+	}
+
+	req = withSession(httptest.NewRequest(http.MethodDelete, "/api/auth/identities/"+strconv.Itoa(int(identities.Identities[0].ID)), nil), login.User.ID, session.ID)
+	w = httptest.NewRecorder()
+	UnlinkIdentity(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", w.Code)
+	}
+}
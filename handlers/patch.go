@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"just-do-it-api/database"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"just-do-it-api/patch"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// readOnlyTaskFields can't be changed by patches. Completion has its own
+// endpoint because it cascades to subtasks and recurrences.
+var readOnlyTaskFields = []string{"id", "user_id", "completed", "occurrence", "progress", "version"}
+
+// PatchTask changes the fields of a task named by a JSON merge patch
+// (RFC 7396), or by the operations of a JSON patch (RFC 6902) when sent as
+// application/json-patch+json. Fields the patch leaves out keep their values.
+func PatchTask(w http.ResponseWriter, r *http.Request) {
+	taskID := strings.TrimPrefix(r.URL.Path, "/v1/tasks/")
+	if taskID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Task ID is required",
+		))
+		return
+	}
+
+	apply := patch.Merge
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case mergePatchType, "application/json", "":
+	case jsonPatchType:
+		apply = patch.Apply
+	default:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Unsupported media type",
+			fmt.Sprintf("Send a %s or %s document", mergePatchType, jsonPatchType),
+		))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to read request body",
+		))
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	var task models.Task
+	userID := middleware.GetUserID(r)
+	if err := db.Where("id = ? AND user_id = ?", taskID, userID).Preload("Tags").First(&task).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Task not found",
+		))
+		return
+	}
+
+	if !checkIfMatch(w, r, db, loc, &task) {
+		return
+	}
+
+	// The patch applies to the task as the client sees it, so test
+	// operations compare against deadlines in the user's time zone
+	localizeTask(loc, &task)
+	original, err := json.Marshal(task)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to update task",
+		))
+		return
+	}
+
+	patched, err := apply(original, body)
+	if errors.Is(err, patch.ErrTestFailed) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Conflict",
+			err.Error(),
+		))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid patch",
+			err.Error(),
+		))
+		return
+	}
+
+	updates, err := decodePatchedTask(original, patched)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid patch",
+			err.Error(),
+		))
+		return
+	}
+
+	// Removing the tags untags the task
+	if updates.Tags == nil {
+		updates.Tags = []models.Tag{}
+	}
+	saveTaskUpdates(w, r, db, loc, userID, &task, updates)
+}
+
+// decodePatchedTask reads the patched task, making sure the patch left the
+// read-only fields alone and only names fields tasks have
+func decodePatchedTask(original, patched []byte) (*models.Task, error) {
+	var before, after map[string]interface{}
+	if err := json.Unmarshal(original, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil || after == nil {
+		return nil, errors.New("the patched task must be an object")
+	}
+	for _, field := range readOnlyTaskFields {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			return nil, fmt.Errorf("%s can't be changed", field)
+		}
+	}
+
+	var task models.Task
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
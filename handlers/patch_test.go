@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/models"
+	"net/http"
+	"testing"
+)
+
+func TestPatchTask(t *testing.T) {
+	setupTest(t)
+	createTaggedTask(t, "patched", "errand")
+
+	rr := handlerRequest(t, PatchTask, http.MethodPatch, "/v1/tasks/patched", `{"description": "Milk and eggs", "priority": "high"}`, "Content-Type", mergePatchType)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	task := loadTask(t, "patched")
+	if task.Title != "Task patched" || task.Description != "Milk and eggs" || task.Priority != models.PriorityHigh {
+		t.Errorf("expected only the description and priority to change, got %+v", task)
+	}
+	if task.Deadline == nil || len(task.Tags) != 1 {
+		t.Errorf("expected the deadline and tags to be kept, got %+v", task)
+	}
+
+	// null clears a field
+	rr = handlerRequest(t, PatchTask, http.MethodPatch, "/v1/tasks/patched", `{"description": null, "deadline": null, "tags": null}`, "Content-Type", mergePatchType)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	task = loadTask(t, "patched")
+	if task.Description != "" || task.Deadline != nil || len(task.Tags) != 0 {
+		t.Errorf("expected the description, deadline and tags to be cleared, got %+v", task)
+	}
+
+	rr = handlerRequest(t, PatchTask, http.MethodPatch, "/v1/tasks/patched", `[
+		{"op": "test", "path": "/title", "value": "Task patched"},
+		{"op": "replace", "path": "/title", "value": "Groceries"},
+		{"op": "add", "path": "/tags/-", "value": "shopping"}
+	]`, "Content-Type", jsonPatchType)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response models.Task
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Title != "Groceries" || len(response.Tags) != 1 || response.Tags[0].Name != "shopping" {
+		t.Errorf("expected the title and tag to change, got %+v", response)
+	}
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		{"Change ID", mergePatchType, `{"id": "other"}`, http.StatusBadRequest},
+		{"Change User", mergePatchType, `{"user_id": 42}`, http.StatusBadRequest},
+		{"Remove ID", jsonPatchType, `[{"op": "remove", "path": "/id"}]`, http.StatusBadRequest},
+		{"Complete", mergePatchType, `{"completed": true}`, http.StatusBadRequest},
+		{"Unknown Field", mergePatchType, `{"colour": "red"}`, http.StatusBadRequest},
+		{"Invalid After Merge", mergePatchType, `{"all_day": true}`, http.StatusBadRequest},
+		{"Clear Title", mergePatchType, `{"title": null}`, http.StatusBadRequest},
+		{"Not An Object", mergePatchType, `["title"]`, http.StatusBadRequest},
+		{"Invalid JSON", mergePatchType, `{"title": `, http.StatusBadRequest},
+		{"Invalid Operation", jsonPatchType, `[{"op": "remove", "path": "/missing"}]`, http.StatusBadRequest},
+		{"Failed Test", jsonPatchType, `[{"op": "test", "path": "/title", "value": "Task patched"}]`, http.StatusConflict},
+		{"Unsupported Media Type", "text/plain", `title=Other`, http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := handlerRequest(t, PatchTask, http.MethodPatch, "/v1/tasks/patched", tt.body, "Content-Type", tt.contentType)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", rr.Code, tt.expectedCode, rr.Body.String())
+			}
+		})
+	}
+
+	if task := loadTask(t, "patched"); task.ID != "patched" || task.Title != "Groceries" || task.Completed || task.AllDay {
+		t.Errorf("expected rejected patches to leave the task alone, got %+v", task)
+	}
+
+	// All-day tasks swap their deadline for a due date in one patch
+	rr = handlerRequest(t, PatchTask, http.MethodPatch, "/v1/tasks/patched", `{"all_day": true, "due_date": "2026-11-02"}`, "Content-Type", mergePatchType)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if task := loadTask(t, "patched"); !task.AllDay || task.DueDate == nil || task.DueDate.String() != "2026-11-02" {
+		t.Errorf("expected an all-day task due on 2026-11-02, got %+v", task)
+	}
+
+	if rr := handlerRequest(t, PatchTask, http.MethodPatch, "/v1/tasks/missing", `{"title": "Other"}`, "Content-Type", mergePatchType); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for an unknown task, got %d", http.StatusNotFound, rr.Code)
+	}
+}
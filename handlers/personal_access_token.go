@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/auth"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func CreatePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePersonalAccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			"Expiration must be in the future",
+		))
+		return
+	}
+
+	token, prefix, err := auth.GeneratePersonalAccessToken()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to generate token",
+		))
+		return
+	}
+
+	pat := models.PersonalAccessToken{
+		UserID:    middleware.GetUserID(r),
+		Name:      req.Name,
+		Prefix:    prefix,
+		TokenHash: auth.HashToken(token),
+		Scopes:    models.ScopeList(req.Scopes),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := db.Create(&pat).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to create token",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.CreatePersonalAccessTokenResponse{
+		Token:               token,
+		PersonalAccessToken: pat,
+	})
+}
+
+func GetPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	var tokens []models.PersonalAccessToken
+
+	userID := middleware.GetUserID(r)
+	result := db.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&tokens)
+	if result.Error != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch tokens",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.PersonalAccessTokensResponse{Tokens: tokens})
+}
+
+func RevokePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	tokenID := strings.TrimPrefix(r.URL.Path, "/api/auth/tokens/")
+	if tokenID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Token ID is required",
+		))
+		return
+	}
+
+	var pat models.PersonalAccessToken
+	userID := middleware.GetUserID(r)
+	if err := db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).First(&pat).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Token not found",
+		))
+		return
+	}
+
+	now := time.Now()
+	pat.RevokedAt = &now
+	if err := db.Save(&pat).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to revoke token",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"just-do-it-api/auth"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreatePersonalAccessToken(t *testing.T) {
+	db = NewAuthMockDB()
+
+	login := loginTestUser(t, "test@example.com")
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name           string
+		payload        models.CreatePersonalAccessTokenRequest
+		expectedStatus int
+	}{
+		{
+			name:           "Valid Token",
+			payload:        models.CreatePersonalAccessTokenRequest{Name: "CI", Scopes: []string{auth.ScopeTasksRead, auth.ScopeTasksWrite}},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Missing Scopes",
+			payload:        models.CreatePersonalAccessTokenRequest{Name: "CI"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown Scope",
+			payload:        models.CreatePersonalAccessTokenRequest{Name: "CI", Scopes: []string{"admin"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Expired",
+			payload:        models.CreatePersonalAccessTokenRequest{Name: "CI", Scopes: []string{auth.ScopeTasksRead}, ExpiresAt: &past},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloadBytes, _ := json.Marshal(tt.payload)
+			req := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/tokens", bytes.NewReader(payloadBytes)), login.User.ID, "session")
+			w := httptest.NewRecorder()
+
+			CreatePersonalAccessToken(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusCreated {
+				var response models.CreatePersonalAccessTokenResponse
+				json.NewDecoder(w.Body).Decode(&response)
+				if !strings.HasPrefix(response.Token, auth.PersonalAccessTokenPrefix) {
+					t.Errorf("unexpected token format %q", response.Token)
+				}
+				if !strings.HasPrefix(response.Token, response.Prefix) {
+					t.Errorf("token does not start with its prefix %q", response.Prefix)
+				}
+			}
+		})
+	}
+}
+
+func TestPersonalAccessTokenLifecycle(t *testing.T) {
+	db = NewAuthMockDB()
+
+	login := loginTestUser(t, "test@example.com")
+
+	payloadBytes, _ := json.Marshal(models.CreatePersonalAccessTokenRequest{Name: "cron", Scopes: []string{auth.ScopeTasksRead}})
+	req := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/tokens", bytes.NewReader(payloadBytes)), login.User.ID, "session")
+	w := httptest.NewRecorder()
+	CreatePersonalAccessToken(w, req)
+
+	var created models.CreatePersonalAccessTokenResponse
+	json.NewDecoder(w.Body).Decode(&created)
+
+	// The token authenticates and its use is recorded
+	pat, err := auth.ValidatePersonalAccessToken(db, created.Token)
+	if err != nil {
+		t.Fatalf("expected token to be valid: %v", err)
+	}
+	if pat.UserID != login.User.ID {
+		t.Errorf("expected token of user %d, got %d", login.User.ID, pat.UserID)
+	}
+
+	var stored models.PersonalAccessToken
+	db.First(&stored, created.ID)
+	if stored.LastUsedAt == nil {
+		t.Error("expected last used timestamp to be set")
+	}
+
+	// Scopes are enforced
+	called := false
+	handler := middleware.RequireScope(auth.ScopeTasksWrite, func(w http.ResponseWriter, r *http.Request) { called = true })
+	scopedReq := httptest.NewRequest(http.MethodPost, "/v1/tasks", nil)
+	scopedReq = scopedReq.WithContext(context.WithValue(scopedReq.Context(), middleware.ScopesKey, pat.Scopes))
+	w = httptest.NewRecorder()
+	handler(w, scopedReq)
+	if called || w.Code != http.StatusForbidden {
+		t.Errorf("expected tasks:write to be rejected for a read-only token, got %d", w.Code)
+	}
+
+	// Listing never shows the token itself
+	req = withSession(httptest.NewRequest(http.MethodGet, "/api/auth/tokens", nil), login.User.ID, "session")
+	w = httptest.NewRecorder()
+	GetPersonalAccessTokens(w, req)
+	if strings.Contains(w.Body.String(), created.Token) {
+		t.Error("expected listing not to contain the token")
+	}
+
+	// Revoked tokens stop working
+	req = withSession(httptest.NewRequest(http.MethodDelete, "/api/auth/tokens/"+strconv.Itoa(int(created.ID)), nil), login.User.ID, "session")
+	w = httptest.NewRecorder()
+	RevokePersonalAccessToken(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	if _, err := auth.ValidatePersonalAccessToken(db, created.Token); err == nil {
+		t.Error("expected revoked token to be rejected")
+	}
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"just-do-it-api/database"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// errProjectNotFound is returned by checkProject for projects the user doesn't own
+var errProjectNotFound = errors.New("project not found")
+
+// userProject loads the project addressed by /v1/projects/{id}[/tasks]
+func userProject(w http.ResponseWriter, r *http.Request, db database.Database) (*models.Project, bool) {
+	projectID, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/projects/"), "/")
+
+	var project models.Project
+	if err := db.Where("id = ? AND user_id = ?", projectID, middleware.GetUserID(r)).First(&project).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Project not found",
+		))
+		return nil, false
+	}
+	return &project, true
+}
+
+// checkProject makes sure a task is only moved into a project of its owner
+func checkProject(db database.Database, userID uint, projectID *uint) error {
+	if projectID == nil {
+		return nil
+	}
+
+	var count int64
+	if err := db.Where("id = ? AND user_id = ?", *projectID, userID).Model(&models.Project{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errProjectNotFound
+	}
+	return nil
+}
+
+// writeProjectError answers a failed checkProject
+func writeProjectError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errProjectNotFound) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Project not found",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(models.NewErrorResponse(
+		"Internal server error",
+		"Failed to check project",
+	))
+}
+
+// includeArchived reports whether the request asks for tasks of archived projects
+func includeArchived(r *http.Request) bool {
+	return r.URL.Query().Get("include_archived") == "true"
+}
+
+// withoutArchivedProjects hides the tasks of archived projects from a task query
+func withoutArchivedProjects(db database.Database, query *gorm.DB) *gorm.DB {
+	archived := db.Where("archived = ?", true).Model(&models.Project{}).Select("id")
+	return query.Where("project_id IS NULL OR project_id NOT IN (?)", archived)
+}
+
+func decodeProjectRequest(w http.ResponseWriter, r *http.Request) (*models.ProjectRequest, bool) {
+	var req models.ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Invalid JSON format",
+		))
+		return nil, false
+	}
+	defer r.Body.Close()
+
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			err.Error(),
+		))
+		return nil, false
+	}
+	return &req, true
+}
+
+func GetProjects(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	var projects []models.Project
+
+	query := db.Where("user_id = ?", middleware.GetUserID(r))
+	if !includeArchived(r) {
+		query = query.Where("archived = ?", false)
+	}
+
+	if err := query.Order("position").Order("id").Find(&projects).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch projects",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ProjectsResponse{Projects: projects})
+}
+
+func GetProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := userProject(w, r, database.CreateConnection())
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// CreateProject adds a project, by default after the existing ones
+func CreateProject(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeProjectRequest(w, r)
+	if !ok {
+		return
+	}
+
+	db := database.CreateConnection()
+	userID := middleware.GetUserID(r)
+	project := models.Project{UserID: userID, Name: req.Name, Color: req.Color}
+	if req.Archived != nil {
+		project.Archived = *req.Archived
+	}
+
+	if req.Position != nil {
+		project.Position = *req.Position
+	} else {
+		var last struct{ Position *int }
+		err := db.Where("user_id = ?", userID).Model(&models.Project{}).Select("MAX(position) AS position").Scan(&last).Error
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Internal server error",
+				"Failed to create project",
+			))
+			return
+		}
+		if last.Position != nil {
+			project.Position = *last.Position + 1
+		}
+	}
+
+	if err := db.Create(&project).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to create project",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(project)
+}
+
+func UpdateProject(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeProjectRequest(w, r)
+	if !ok {
+		return
+	}
+
+	db := database.CreateConnection()
+	project, ok := userProject(w, r, db)
+	if !ok {
+		return
+	}
+
+	project.Name = req.Name
+	project.Color = req.Color
+	if req.Archived != nil {
+		project.Archived = *req.Archived
+	}
+	if req.Position != nil {
+		project.Position = *req.Position
+	}
+
+	if err := db.Save(project).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to update project",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// DeleteProject deletes the project. Its tasks are kept without a project.
+func DeleteProject(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	project, ok := userProject(w, r, db)
+	if !ok {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&models.Task{}).Where("project_id = ?", project.ID).Updates(map[string]interface{}{
+			"project_id": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(project).Error
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to delete project",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetProjectTasks(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+	project, ok := userProject(w, r, db)
+	if !ok {
+		return
+	}
+
+	query, ok := taskFilter(w, r, db, db.Where("project_id = ?", project.ID), loc)
+	if !ok {
+		return
+	}
+
+	var tasks []models.Task
+	err := query.Order(dueColumn).Preload("Tags").Find(&tasks).Error
+	if err == nil {
+		err = withProgress(db, tasks)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch tasks",
+		))
+		return
+	}
+
+	localize(loc, tasks)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TaskResponse{Tasks: tasks})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func createProject(t *testing.T, name string) models.Project {
+	t.Helper()
+
+	rr := handlerRequest(t, CreateProject, http.MethodPost, "/v1/projects", models.ProjectRequest{Name: name})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d creating the project, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	var project models.Project
+	if err := json.NewDecoder(rr.Body).Decode(&project); err != nil {
+		t.Fatal(err)
+	}
+	return project
+}
+
+func TestCreateProject(t *testing.T) {
+	setupTest(t)
+
+	first := createProject(t, "Work")
+	second := createProject(t, "Home")
+	if second.Position != first.Position+1 {
+		t.Errorf("expected new projects to be appended, got positions %d and %d", first.Position, second.Position)
+	}
+
+	tests := []struct {
+		name         string
+		payload      models.ProjectRequest
+		expectedCode int
+	}{
+		{"Missing Name", models.ProjectRequest{Name: " "}, http.StatusBadRequest},
+		{"Invalid Color", models.ProjectRequest{Name: "Garden", Color: "green"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := handlerRequest(t, CreateProject, http.MethodPost, "/v1/projects", tt.payload)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedCode)
+			}
+		})
+	}
+}
+
+func TestMoveTaskBetweenProjects(t *testing.T) {
+	setupTest(t)
+
+	work := createProject(t, "Work")
+	home := createProject(t, "Home")
+
+	rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{
+		"id":         "report",
+		"title":      "Write report",
+		"deadline":   time.Now().Add(24 * time.Hour),
+		"project_id": work.ID,
+	})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if ids := taskIDs(t, GetProjectTasks, fmt.Sprintf("/v1/projects/%d/tasks", work.ID)); ids != "report" {
+		t.Errorf("expected the task in the work project, got %q", ids)
+	}
+
+	rr = handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/report", map[string]interface{}{
+		"title":      "Write report",
+		"deadline":   time.Now().Add(24 * time.Hour),
+		"project_id": home.ID,
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if ids := taskIDs(t, GetProjectTasks, fmt.Sprintf("/v1/projects/%d/tasks", work.ID)); ids != "" {
+		t.Errorf("expected the work project to be empty, got %q", ids)
+	}
+	if ids := taskIDs(t, GetProjectTasks, fmt.Sprintf("/v1/projects/%d/tasks", home.ID)); ids != "report" {
+		t.Errorf("expected the task in the home project, got %q", ids)
+	}
+
+	rr = handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/report", map[string]interface{}{
+		"title":      "Write report",
+		"deadline":   time.Now().Add(24 * time.Hour),
+		"project_id": 999,
+	})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown project, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	// Deleting the project keeps the task
+	rr = handlerRequest(t, DeleteProject, http.MethodDelete, fmt.Sprintf("/v1/projects/%d", home.ID), nil)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+
+	var task models.Task
+	if err := database.CreateConnection().Where("id = ?", "report").First(&task).Error; err != nil {
+		t.Fatal(err)
+	}
+	if task.ProjectID != nil {
+		t.Errorf("expected the task to have no project, got %d", *task.ProjectID)
+	}
+}
+
+func TestArchivedProjectsAreHidden(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+	db.Where("1 = 1").Delete(&models.Task{})
+
+	project := createProject(t, "Old")
+	now := time.Now().UTC()
+	laterToday := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC)
+	tasks := []models.Task{
+		{ID: "today", Title: "Today", Deadline: timePtr(laterToday)},
+		{ID: "today-archived", Title: "Today", Deadline: timePtr(laterToday), ProjectID: &project.ID},
+		{ID: "overdue", Title: "Overdue", Deadline: timePtr(now.Add(-48 * time.Hour))},
+		{ID: "overdue-archived", Title: "Overdue", Deadline: timePtr(now.Add(-48 * time.Hour)), ProjectID: &project.ID},
+	}
+	for _, task := range tasks {
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archived := true
+	rr := handlerRequest(t, UpdateProject, http.MethodPut, fmt.Sprintf("/v1/projects/%d", project.ID), models.ProjectRequest{Name: "Old", Archived: &archived})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		path     string
+		expected string
+	}{
+		{"Today", GetTodayTasks, "/v1/tasks/today", "today"},
+		{"Today Including Archived", GetTodayTasks, "/v1/tasks/today?include_archived=true", "today,today-archived"},
+		{"Backlog", GetBacklogTasks, "/v1/tasks/backlog", "overdue"},
+		{"Backlog Including Archived", GetBacklogTasks, "/v1/tasks/backlog?include_archived=true", "overdue,overdue-archived"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ids := taskIDs(t, tt.handler, tt.path); ids != tt.expected {
+				t.Errorf("got %q want %q", ids, tt.expected)
+			}
+		})
+	}
+
+	rr = handlerRequest(t, GetProjects, http.MethodGet, "/v1/projects", nil)
+	var response models.ProjectsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Projects) != 0 {
+		t.Errorf("expected archived projects to be hidden from the list, got %+v", response.Projects)
+	}
+}
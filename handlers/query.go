@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"just-do-it-api/database"
+	"just-do-it-api/filter"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// taskFilter restricts a task query to the tasks matched by the filter
+// expression in the q parameter and the saved query named by saved_query,
+// given by ID or name
+func taskFilter(w http.ResponseWriter, r *http.Request, db database.Database, query *gorm.DB, loc *time.Location) (*gorm.DB, bool) {
+	env := filter.Env{UserID: middleware.GetUserID(r), Location: loc}
+
+	if q := r.URL.Query().Get("q"); q != "" {
+		var err error
+		if query, err = applyFilter(query, q, env); err != nil {
+			writeQueryError(w, err)
+			return nil, false
+		}
+	}
+
+	if name := r.URL.Query().Get("saved_query"); name != "" {
+		saved := db.Where("user_id = ?", env.UserID)
+		if _, err := strconv.ParseUint(name, 10, 64); err == nil {
+			saved = saved.Where("id = ?", name)
+		} else {
+			saved = saved.Where("name = ?", name)
+		}
+
+		var savedQuery models.SavedQuery
+		if err := saved.First(&savedQuery).Error; err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Not found",
+				"Saved query not found",
+			))
+			return nil, false
+		}
+
+		var err error
+		if query, err = applyFilter(query, savedQuery.Query, env); err != nil {
+			writeQueryError(w, err)
+			return nil, false
+		}
+	}
+	return query, true
+}
+
+// applyFilter parses and compiles a filter expression into a condition of the query
+func applyFilter(query *gorm.DB, expression string, env filter.Env) (*gorm.DB, error) {
+	expr, err := filter.Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	condition, args, err := filter.Compile(expr, env)
+	if err != nil {
+		return nil, err
+	}
+	return query.Where(condition, args...), nil
+}
+
+// writeQueryError answers an invalid filter expression with the position of the problem
+func writeQueryError(w http.ResponseWriter, err error) {
+	var filterErr *filter.Error
+	if !errors.As(err, &filterErr) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to apply query",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(models.QueryErrorResponse{
+		ErrorResponse: models.NewErrorResponse("Invalid query", filterErr.Message),
+		Position:      filterErr.Pos,
+	})
+}
+
+// userSavedQuery loads the saved query addressed by /v1/queries/{id}[/tasks]
+func userSavedQuery(w http.ResponseWriter, r *http.Request, db database.Database) (*models.SavedQuery, bool) {
+	queryID, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/queries/"), "/")
+
+	var savedQuery models.SavedQuery
+	if err := db.Where("id = ? AND user_id = ?", queryID, middleware.GetUserID(r)).First(&savedQuery).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Saved query not found",
+		))
+		return nil, false
+	}
+	return &savedQuery, true
+}
+
+// decodeSavedQueryRequest reads a saved query and makes sure its expression is valid
+func decodeSavedQueryRequest(w http.ResponseWriter, r *http.Request) (*models.SavedQueryRequest, bool) {
+	var req models.SavedQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Invalid JSON format",
+		))
+		return nil, false
+	}
+	defer r.Body.Close()
+
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			err.Error(),
+		))
+		return nil, false
+	}
+
+	expr, err := filter.Parse(req.Query)
+	if err == nil {
+		_, _, err = filter.Compile(expr, filter.Env{UserID: middleware.GetUserID(r)})
+	}
+	if err != nil {
+		writeQueryError(w, err)
+		return nil, false
+	}
+	return &req, true
+}
+
+// savedQueryNameTaken reports whether the user has another saved query with this name
+func savedQueryNameTaken(db database.Database, userID uint, name string, exceptID uint) (bool, error) {
+	var count int64
+	err := db.Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).Model(&models.SavedQuery{}).Count(&count).Error
+	return count > 0, err
+}
+
+func GetSavedQueries(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	savedQueries := []models.SavedQuery{}
+
+	if err := db.Where("user_id = ?", middleware.GetUserID(r)).Order("name").Find(&savedQueries).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch saved queries",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SavedQueriesResponse{SavedQueries: savedQueries})
+}
+
+func GetSavedQuery(w http.ResponseWriter, r *http.Request) {
+	savedQuery, ok := userSavedQuery(w, r, database.CreateConnection())
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(savedQuery)
+}
+
+func CreateSavedQuery(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeSavedQueryRequest(w, r)
+	if !ok {
+		return
+	}
+
+	db := database.CreateConnection()
+	userID := middleware.GetUserID(r)
+	taken, err := savedQueryNameTaken(db, userID, req.Name, 0)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to create saved query",
+		))
+		return
+	}
+	if taken {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Conflict",
+			"A saved query with this name already exists",
+		))
+		return
+	}
+
+	savedQuery := models.SavedQuery{UserID: userID, Name: req.Name, Query: req.Query}
+	if err := db.Create(&savedQuery).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to create saved query",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(savedQuery)
+}
+
+func UpdateSavedQuery(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeSavedQueryRequest(w, r)
+	if !ok {
+		return
+	}
+
+	db := database.CreateConnection()
+	savedQuery, ok := userSavedQuery(w, r, db)
+	if !ok {
+		return
+	}
+
+	taken, err := savedQueryNameTaken(db, savedQuery.UserID, req.Name, savedQuery.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to update saved query",
+		))
+		return
+	}
+	if taken {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Conflict",
+			"A saved query with this name already exists",
+		))
+		return
+	}
+
+	savedQuery.Name = req.Name
+	savedQuery.Query = req.Query
+	if err := db.Save(savedQuery).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to update saved query",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(savedQuery)
+}
+
+func DeleteSavedQuery(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	savedQuery, ok := userSavedQuery(w, r, db)
+	if !ok {
+		return
+	}
+
+	if err := db.Delete(savedQuery).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to delete saved query",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSavedQueryTasks lists the tasks a saved query matches, like GetTasks
+func GetSavedQueryTasks(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+	opts, ok := parseListOptions(w, r, "created_at")
+	if !ok {
+		return
+	}
+	savedQuery, ok := userSavedQuery(w, r, db)
+	if !ok {
+		return
+	}
+
+	env := filter.Env{UserID: savedQuery.UserID, Location: loc}
+	query, err := applyFilter(db.Where("user_id = ?", savedQuery.UserID), savedQuery.Query, env)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	tasks, next, err := listTasks(db, query, opts)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch tasks",
+		))
+		return
+	}
+
+	localize(loc, tasks)
+	writeTaskList(w, tasks, next, opts)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"just-do-it-api/models"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func createFilterTasks(t *testing.T) {
+	t.Helper()
+
+	project := createProject(t, "Side Project")
+	tasks := []map[string]interface{}{
+		{"id": "report", "title": "Quarterly report", "deadline": time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC), "priority": "high", "tags": []string{"work"}},
+		{"id": "slides", "title": "Slides", "deadline": time.Date(2026, 12, 1, 9, 0, 0, 0, time.UTC), "priority": "low", "tags": []string{"work"}},
+		{"id": "garden", "title": "Garden", "all_day": true, "due_date": "2026-10-30", "priority": "urgent", "tags": []string{"home"}},
+		{"id": "website", "title": "Website", "priority": "medium", "project_id": project.ID},
+	}
+	for _, task := range tasks {
+		if rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", task); rr.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+	}
+	handlerRequest(t, ToggleTask, http.MethodPatch, "/v1/tasks/slides/toggle", nil)
+}
+
+func TestFilterTasks(t *testing.T) {
+	setupTest(t)
+	createFilterTasks(t)
+
+	tests := []struct {
+		name     string
+		q        string
+		expected string
+	}{
+		{"Completed", "completed:true", "2,slides"},
+		{"Tag And Priority", "tag:work AND priority>=high", "report"},
+		{"Example", "completed:false AND deadline<2026-11-01 AND (tag:work OR priority>=high)", "garden,report"},
+		{"Negation Keeps Undated Tasks", "NOT deadline<2026-11-01", "slides,website"},
+		{"Undated", "deadline:none", "website"},
+		{"Project By Name", `project:"side project"`, "website"},
+		{"Not In Project", "project!=none", "website"},
+		{"Untagged", "tag:none", "1,2,website"},
+		{"Title", "title:REPORT OR title=Garden", "garden,report"},
+		{"Implicit And", "tag:work completed:false", "report"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := taskIDs(t, GetTasks, "/v1/tasks?"+url.Values{"q": {tt.q}}.Encode())
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	// Other listings take the same expressions
+	if got := taskIDs(t, GetMatrixTasks, "/v1/tasks/matrix?q=tag:home"); got != "" {
+		t.Errorf("expected the matrix to be filtered, got %q", got)
+	}
+	if got := taskIDs(t, GetBacklogTasks, "/v1/tasks/backlog?q=tag:home"); got != "" {
+		t.Errorf("expected no tasks in the backlog, got %q", got)
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	setupTest(t)
+
+	tests := []struct {
+		q        string
+		position int
+	}{
+		{"completed:false AND (tag:work OR", 32},
+		{"completed:false AND color:red", 20},
+		{"priority>=highest", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			rr := handlerRequest(t, GetTasks, http.MethodGet, "/v1/tasks?"+url.Values{"q": {tt.q}}.Encode(), nil)
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusBadRequest, rr.Code, rr.Body.String())
+			}
+
+			var response models.QueryErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Error != "Invalid query" || response.Position != tt.position {
+				t.Errorf("expected an invalid query at position %d, got %+v", tt.position, response)
+			}
+		})
+	}
+}
+
+func TestSavedQueries(t *testing.T) {
+	setupTest(t)
+	createFilterTasks(t)
+
+	rr := handlerRequest(t, CreateSavedQuery, http.MethodPost, "/v1/queries", models.SavedQueryRequest{Name: "Open work", Query: "tag:work completed:false"})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var saved models.SavedQuery
+	if err := json.NewDecoder(rr.Body).Decode(&saved); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		request      models.SavedQueryRequest
+		expectedCode int
+	}{
+		{"Duplicate Name", models.SavedQueryRequest{Name: "Open work", Query: "tag:work"}, http.StatusConflict},
+		{"Invalid Query", models.SavedQueryRequest{Name: "Broken", Query: "tag:"}, http.StatusBadRequest},
+		{"Unknown Field", models.SavedQueryRequest{Name: "Broken", Query: "color:red"}, http.StatusBadRequest},
+		{"Missing Name", models.SavedQueryRequest{Query: "tag:work"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := handlerRequest(t, CreateSavedQuery, http.MethodPost, "/v1/queries", tt.request)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", rr.Code, tt.expectedCode, rr.Body.String())
+			}
+		})
+	}
+
+	path := fmt.Sprintf("/v1/queries/%d", saved.ID)
+	if got := taskIDs(t, GetSavedQueryTasks, path+"/tasks"); got != "report" {
+		t.Errorf("expected the saved query to match report, got %q", got)
+	}
+	if got := taskIDs(t, GetTasks, "/v1/tasks?saved_query=Open+work"); got != "report" {
+		t.Errorf("expected the saved query by name to match report, got %q", got)
+	}
+	if got := taskIDs(t, GetTodayTasks, fmt.Sprintf("/v1/tasks/today?saved_query=%d&q=priority:low", saved.ID)); got != "" {
+		t.Errorf("expected the saved query and q to be combined, got %q", got)
+	}
+	if rr := handlerRequest(t, GetTasks, http.MethodGet, "/v1/tasks?saved_query=Missing", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for an unknown saved query, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	rr = handlerRequest(t, UpdateSavedQuery, http.MethodPut, path, models.SavedQueryRequest{Name: "All work", Query: "tag:work"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if got := taskIDs(t, GetTasks, "/v1/tasks?saved_query=All+work"); got != "report,slides" {
+		t.Errorf("expected the updated query to match report and slides, got %q", got)
+	}
+
+	if rr := handlerRequest(t, DeleteSavedQuery, http.MethodDelete, path, nil); rr.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if rr := handlerRequest(t, GetSavedQuery, http.MethodGet, path, nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d after deleting, got %d", http.StatusNotFound, rr.Code)
+	}
+}
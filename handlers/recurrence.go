@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"just-do-it-api/database"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"just-do-it-api/recurrence"
+	"just-do-it-api/reminders"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// normalizeRecurrence validates the recurrence rule and time zone of a task
+// and stores the rule in its canonical form
+func normalizeRecurrence(task *models.Task) error {
+	if strings.TrimSpace(task.Recurrence) == "" {
+		task.Recurrence = ""
+		task.RecurrenceTimezone = ""
+		task.Occurrence = 0
+		return nil
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return fmt.Errorf("invalid recurrence: %v", err)
+	}
+	if !task.Dated() {
+		return fmt.Errorf("recurring tasks need a deadline or due_date")
+	}
+	task.Recurrence = rule.String()
+
+	if task.RecurrenceTimezone == "" {
+		task.RecurrenceTimezone = "UTC"
+	}
+	if _, err := time.LoadLocation(task.RecurrenceTimezone); err != nil {
+		return fmt.Errorf("invalid recurrence time zone %q", task.RecurrenceTimezone)
+	}
+
+	if task.Occurrence == 0 {
+		task.Occurrence = 1
+	}
+	return nil
+}
+
+// advance moves the deadline or due date of the task to its next
+// occurrence, and its start date along with it. It returns false when the
+// series is over.
+func advance(task *models.Task) (bool, error) {
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return false, err
+	}
+
+	// Due dates are civil dates, so they repeat from midnight UTC
+	loc := time.UTC
+	var prev time.Time
+	if task.AllDay {
+		prev = task.DueDate.In(time.UTC)
+	} else {
+		if loc, err = time.LoadLocation(task.RecurrenceTimezone); err != nil {
+			return false, err
+		}
+		prev = *task.Deadline
+	}
+
+	next, ok := rule.Next(prev, task.Occurrence, loc)
+	if !ok {
+		return false, nil
+	}
+
+	if task.StartDate != nil {
+		days := int(models.DateOf(next.In(loc)).In(time.UTC).Sub(models.DateOf(prev.In(loc)).In(time.UTC)).Hours() / 24)
+		start := models.DateOf(task.StartDate.In(time.UTC).AddDate(0, 0, days))
+		task.StartDate = &start
+	}
+	if task.AllDay {
+		date := models.DateOf(next)
+		task.DueDate = &date
+	} else {
+		task.Deadline = &next
+	}
+	return true, nil
+}
+
+// createNextOccurrence adds the occurrence following a completed recurring
+// task, along with its relative reminders. The rule moves on to the new task,
+// so completing the same occurrence twice doesn't create another one. It
+// returns nil when the series is over.
+func createNextOccurrence(tx *gorm.DB, task *models.Task, loc *time.Location) (*models.Task, error) {
+	due := *task
+	ok, err := advance(&due)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	next := models.Task{
+		UserID:             task.UserID,
+		Title:              task.Title,
+		Description:        task.Description,
+		Deadline:           due.Deadline,
+		AllDay:             task.AllDay,
+		DueDate:            due.DueDate,
+		StartDate:          due.StartDate,
+		Priority:           task.Priority,
+		Important:          task.Important,
+		Urgent:             task.Urgent,
+		ProjectID:          task.ProjectID,
+		ParentID:           task.ParentID,
+		Recurrence:         task.Recurrence,
+		RecurrenceTimezone: task.RecurrenceTimezone,
+		Occurrence:         task.Occurrence + 1,
+		Tags:               task.Tags,
+	}
+	if err := tx.Create(&next).Error; err != nil {
+		return nil, err
+	}
+	if err := reminders.CopyRelative(tx, task.ID, &next, loc); err != nil {
+		return nil, err
+	}
+
+	task.Recurrence = ""
+	task.RecurrenceTimezone = ""
+	if err := saveTask(tx, task); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// recurringTask loads the open recurring task addressed by /v1/tasks/{id}/{action}
+func recurringTask(w http.ResponseWriter, r *http.Request, db database.Database, action string) (*models.Task, bool) {
+	taskID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/tasks/"), "/"+action)
+
+	var task models.Task
+	if err := db.Where("id = ? AND user_id = ?", taskID, middleware.GetUserID(r)).Preload("Tags").First(&task).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Task not found",
+		))
+		return nil, false
+	}
+
+	if task.Recurrence == "" || task.Completed {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not recurring",
+			"Task is not an open recurring task",
+		))
+		return nil, false
+	}
+	return &task, true
+}
+
+// SkipOccurrence moves a recurring task to its next occurrence without completing it
+func SkipOccurrence(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+	task, ok := recurringTask(w, r, db, "skip")
+	if !ok {
+		return
+	}
+
+	before := taskSnapshot(task)
+	ok, err := advance(task)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to skip occurrence",
+		))
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Series over",
+			"This is the last occurrence, delete the task instead",
+		))
+		return
+	}
+
+	task.Occurrence++
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := saveTask(tx, task); err != nil {
+			return err
+		}
+		if err := reminders.Rearm(tx, task, loc); err != nil {
+			return err
+		}
+		return recordTaskEvent(tx, r, task.ID, task.UserID, models.TaskUpdated, diffTasks(before, taskSnapshot(task)))
+	})
+	if errors.Is(err, errVersionConflict) {
+		writeVersionConflict(w, db, loc, task.ID)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to skip occurrence",
+		))
+		return
+	}
+
+	updated := []models.Task{*task}
+	if err := withProgress(db, updated); err == nil {
+		*task = updated[0]
+	}
+	localizeTask(loc, task)
+	writeTask(w, http.StatusOK, loc, task)
+}
+
+// StopRecurrence removes the recurrence rule, keeping the task as a single one
+func StopRecurrence(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+	task, ok := recurringTask(w, r, db, "stop-recurring")
+	if !ok {
+		return
+	}
+
+	before := taskSnapshot(task)
+	task.Recurrence = ""
+	task.RecurrenceTimezone = ""
+	task.Occurrence = 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := saveTask(tx, task); err != nil {
+			return err
+		}
+		return recordTaskEvent(tx, r, task.ID, task.UserID, models.TaskUpdated, diffTasks(before, taskSnapshot(task)))
+	})
+	if errors.Is(err, errVersionConflict) {
+		writeVersionConflict(w, db, loc, task.ID)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to stop recurrence",
+		))
+		return
+	}
+
+	updated := []models.Task{*task}
+	if err := withProgress(db, updated); err == nil {
+		*task = updated[0]
+	}
+	localizeTask(loc, task)
+	writeTask(w, http.StatusOK, loc, task)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func createRecurringTask(t *testing.T, id string, deadline time.Time, rule string, timezone string) int {
+	t.Helper()
+
+	rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{
+		"id":                  id,
+		"title":               "Water the plants",
+		"deadline":            deadline,
+		"recurrence":          rule,
+		"recurrence_timezone": timezone,
+		"tags":                []string{"home"},
+	})
+	return rr.Code
+}
+
+// toggleRecurring toggles a task and returns the ID of the next occurrence, if one was created
+func toggleRecurring(t *testing.T, id string) string {
+	t.Helper()
+
+	rr := handlerRequest(t, ToggleTask, http.MethodPatch, "/v1/tasks/"+id+"/toggle", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var response struct {
+		NextTaskID string `json:"next_task_id"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return response.NextTaskID
+}
+
+func TestCreateRecurringTask(t *testing.T) {
+	setupTest(t)
+
+	tests := []struct {
+		name         string
+		id           string
+		rule         string
+		timezone     string
+		expectedCode int
+	}{
+		{"Valid Rule", "valid", "RRULE:FREQ=WEEKLY;BYDAY=MO,TH", "Europe/Berlin", http.StatusCreated},
+		{"Invalid Rule", "invalid-rule", "FREQ=HOURLY", "", http.StatusBadRequest},
+		{"Invalid Time Zone", "invalid-zone", "FREQ=DAILY", "Mars/Olympus_Mons", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := createRecurringTask(t, tt.id, time.Now().Add(time.Hour), tt.rule, tt.timezone)
+			if code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", code, tt.expectedCode)
+			}
+		})
+	}
+
+	task := loadTask(t, "valid")
+	if task.Recurrence != "FREQ=WEEKLY;BYDAY=MO,TH" || task.Occurrence != 1 {
+		t.Errorf("expected the canonical rule and the first occurrence, got %q and %d", task.Recurrence, task.Occurrence)
+	}
+}
+
+func TestCompletingRecurringTaskCreatesNextOccurrence(t *testing.T) {
+	setupTest(t)
+
+	// Daylight saving time ends on October 25, 2026 in Berlin
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	deadline := time.Date(2026, 10, 24, 18, 0, 0, 0, berlin)
+	if code := createRecurringTask(t, "plants", deadline, "FREQ=DAILY;COUNT=2", "Europe/Berlin"); code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, code)
+	}
+
+	nextID := toggleRecurring(t, "plants")
+	if nextID == "" {
+		t.Fatal("expected the next occurrence to be created")
+	}
+
+	var next models.Task
+	if err := database.CreateConnection().Where("id = ?", nextID).Preload("Tags").First(&next).Error; err != nil {
+		t.Fatal(err)
+	}
+	if local := next.Deadline.In(berlin); local.Day() != 25 || local.Hour() != 18 {
+		t.Errorf("expected the next occurrence at 18:00 on October 25, got %v", local)
+	}
+	if next.Occurrence != 2 || next.Recurrence == "" || len(next.Tags) != 1 {
+		t.Errorf("expected the next occurrence to continue the series, got %+v", next)
+	}
+	if loadTask(t, "plants").Recurrence != "" {
+		t.Error("expected the rule to move to the next occurrence")
+	}
+
+	// Completing the same occurrence again doesn't create another one
+	toggleRecurring(t, "plants")
+	if id := toggleRecurring(t, "plants"); id != "" {
+		t.Errorf("expected no further occurrence, got %s", id)
+	}
+
+	// COUNT=2 ends the series with the second occurrence
+	if id := toggleRecurring(t, next.ID); id != "" {
+		t.Errorf("expected the series to end, got %s", id)
+	}
+}
+
+func TestSkipAndStopRecurrence(t *testing.T) {
+	setupTest(t)
+
+	deadline := time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC)
+	if code := createRecurringTask(t, "rent", deadline, "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=2", ""); code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, code)
+	}
+
+	rr := handlerRequest(t, SkipOccurrence, http.MethodPost, "/v1/tasks/rent/skip", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	task := loadTask(t, "rent")
+	if !task.Deadline.Equal(time.Date(2026, 2, 28, 9, 0, 0, 0, time.UTC)) || task.Occurrence != 2 {
+		t.Errorf("expected the task to move to February 28, got %v (occurrence %d)", task.Deadline, task.Occurrence)
+	}
+
+	rr = handlerRequest(t, SkipOccurrence, http.MethodPost, "/v1/tasks/rent/skip", nil)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected status %d skipping the last occurrence, got %d", http.StatusConflict, rr.Code)
+	}
+
+	rr = handlerRequest(t, StopRecurrence, http.MethodPost, "/v1/tasks/rent/stop-recurring", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if loadTask(t, "rent").Recurrence != "" {
+		t.Error("expected the rule to be removed")
+	}
+
+	rr = handlerRequest(t, StopRecurrence, http.MethodPost, "/v1/tasks/rent/stop-recurring", nil)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected status %d for a task that doesn't recur, got %d", http.StatusConflict, rr.Code)
+	}
+}
+
+func TestAllDayRecurrenceMovesStartDate(t *testing.T) {
+	setupTest(t)
+
+	rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{
+		"id":         "taxes",
+		"title":      "File taxes",
+		"all_day":    true,
+		"due_date":   "2026-04-15",
+		"start_date": "2026-04-01",
+		"recurrence": "FREQ=MONTHLY;BYMONTHDAY=15",
+	})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	next := loadTask(t, toggleRecurring(t, "taxes"))
+	if !next.AllDay || next.Deadline != nil || next.DueDate.String() != "2026-05-15" || next.StartDate.String() != "2026-05-01" {
+		t.Errorf("expected the next occurrence due on 2026-05-15 and starting on 2026-05-01, got %+v", next)
+	}
+}
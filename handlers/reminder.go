@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/database"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"just-do-it-api/reminders"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// reminderTask loads the task addressed by /v1/tasks/{id}/reminders
+func reminderTask(w http.ResponseWriter, r *http.Request, db database.Database) (*models.Task, bool) {
+	taskID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/tasks/"), "/reminders")
+
+	var task models.Task
+	if err := db.Where("id = ? AND user_id = ?", taskID, middleware.GetUserID(r)).First(&task).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Task not found",
+		))
+		return nil, false
+	}
+	return &task, true
+}
+
+// GetReminders lists the reminders of a task, the next one first
+func GetReminders(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	task, ok := reminderTask(w, r, db)
+	if !ok {
+		return
+	}
+
+	var taskReminders []models.Reminder
+	if err := db.Where("task_id = ?", task.ID).Order("fire_at").Find(&taskReminders).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch reminders",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RemindersResponse{Reminders: taskReminders})
+}
+
+// CreateReminder adds a reminder to a task, either relative to its deadline or at a fixed time
+func CreateReminder(w http.ResponseWriter, r *http.Request) {
+	var req models.ReminderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Invalid JSON format",
+		))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			err.Error(),
+		))
+		return
+	}
+
+	if (req.Before == "") == (req.At == nil) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Either before or at is required",
+		))
+		return
+	}
+
+	reminder := models.Reminder{
+		Channel: req.Channel,
+		At:      req.At,
+		Status:  models.ReminderPending,
+	}
+	if req.Before != "" {
+		before, err := reminders.ParseBefore(req.Before)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Invalid request",
+				err.Error(),
+			))
+			return
+		}
+		reminder.Before = before.String()
+	}
+	if req.At != nil && req.At.Before(time.Now()) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Reminder time is in the past",
+		))
+		return
+	}
+	if req.Channel == models.ChannelWebhook {
+		if u, err := url.Parse(req.WebhookURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Invalid request",
+				"Webhook URL must be an http or https URL",
+			))
+			return
+		}
+		reminder.WebhookURL = req.WebhookURL
+	}
+
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+	task, ok := reminderTask(w, r, db)
+	if !ok {
+		return
+	}
+
+	// All-day tasks are due at the end of their due date in the user's time zone
+	fireAt, err := reminders.FireAt(&reminder, task.DueAt(loc))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			err.Error(),
+		))
+		return
+	}
+	reminder.TaskID = task.ID
+	reminder.UserID = task.UserID
+	reminder.FireAt = fireAt
+
+	if err := db.Create(&reminder).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to create reminder",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reminder)
+}
+
+// DeleteReminder removes a reminder
+func DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	reminderID := strings.TrimPrefix(r.URL.Path, "/v1/reminders/")
+
+	db := database.CreateConnection()
+	result := db.Where("id = ? AND user_id = ?", reminderID, middleware.GetUserID(r)).Delete(&models.Reminder{})
+	if result.Error != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to delete reminder",
+		))
+		return
+	}
+	if result.RowsAffected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Reminder not found",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetNotifications lists the in-app notifications of the user, newest first.
+// With unread=true only unread ones are returned.
+func GetNotifications(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	query := db.Where("user_id = ?", middleware.GetUserID(r))
+	if r.URL.Query().Get("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC, id DESC").Find(&notifications).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch notifications",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NotificationsResponse{Notifications: notifications})
+}
+
+// MarkNotificationRead marks an in-app notification as read
+func MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	notificationID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/notifications/"), "/read")
+
+	db := database.CreateConnection()
+	var notification models.Notification
+	if err := db.Where("id = ? AND user_id = ?", notificationID, middleware.GetUserID(r)).First(&notification).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Notification not found",
+		))
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := db.Save(&notification).Error; err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Internal server error",
+				"Failed to update notification",
+			))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notification)
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func createReminder(t *testing.T, taskID string, payload map[string]interface{}) models.Reminder {
+	t.Helper()
+
+	rr := handlerRequest(t, CreateReminder, http.MethodPost, "/v1/tasks/"+taskID+"/reminders", payload)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d creating the reminder, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	var reminder models.Reminder
+	if err := json.NewDecoder(rr.Body).Decode(&reminder); err != nil {
+		t.Fatal(err)
+	}
+	return reminder
+}
+
+func TestCreateReminder(t *testing.T) {
+	setupTest(t)
+
+	deadline := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{
+		"id":       "remind",
+		"title":    "Submit report",
+		"deadline": deadline,
+	})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		payload      map[string]interface{}
+		expectedCode int
+	}{
+		{"Relative", "/v1/tasks/remind/reminders", map[string]interface{}{"channel": "email", "before": "90m"}, http.StatusCreated},
+		{"Absolute", "/v1/tasks/remind/reminders", map[string]interface{}{"channel": "in_app", "at": time.Now().Add(time.Hour)}, http.StatusCreated},
+		{"Webhook", "/v1/tasks/remind/reminders", map[string]interface{}{"channel": "webhook", "before": "1h", "webhook_url": "https://example.com/hook"}, http.StatusCreated},
+		{"Neither Before Nor At", "/v1/tasks/remind/reminders", map[string]interface{}{"channel": "email"}, http.StatusBadRequest},
+		{"Both Before And At", "/v1/tasks/remind/reminders", map[string]interface{}{"channel": "email", "before": "1h", "at": time.Now().Add(time.Hour)}, http.StatusBadRequest},
+		{"Invalid Offset", "/v1/tasks/remind/reminders", map[string]interface{}{"channel": "email", "before": "-1h"}, http.StatusBadRequest},
+		{"At In The Past", "/v1/tasks/remind/reminders", map[string]interface{}{"channel": "email", "at": time.Now().Add(-time.Hour)}, http.StatusBadRequest},
+		{"Unknown Channel", "/v1/tasks/remind/reminders", map[string]interface{}{"channel": "sms", "before": "1h"}, http.StatusBadRequest},
+		{"Webhook Without URL", "/v1/tasks/remind/reminders", map[string]interface{}{"channel": "webhook", "before": "1h"}, http.StatusBadRequest},
+		{"Webhook With Other Scheme", "/v1/tasks/remind/reminders", map[string]interface{}{"channel": "webhook", "before": "1h", "webhook_url": "ftp://example.com"}, http.StatusBadRequest},
+		{"Unknown Task", "/v1/tasks/missing/reminders", map[string]interface{}{"channel": "email", "before": "1h"}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := handlerRequest(t, CreateReminder, http.MethodPost, tt.path, tt.payload)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", rr.Code, tt.expectedCode, rr.Body.String())
+			}
+		})
+	}
+
+	rr = handlerRequest(t, GetReminders, http.MethodGet, "/v1/tasks/remind/reminders", nil)
+	var response models.RemindersResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Reminders) != 3 {
+		t.Fatalf("expected 3 reminders, got %d", len(response.Reminders))
+	}
+
+	// Ordered by when they fire: the absolute one, then 90 and 60 minutes before the deadline
+	relative := response.Reminders[1]
+	if relative.Before != "1h30m0s" || !relative.FireAt.Equal(deadline.Add(-90*time.Minute)) || relative.Status != models.ReminderPending {
+		t.Errorf("expected a pending reminder 90 minutes before the deadline, got %+v", relative)
+	}
+}
+
+func TestUpdateTaskRearmsReminders(t *testing.T) {
+	setupTest(t)
+
+	deadline := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{
+		"id":       "moved",
+		"title":    "Dentist",
+		"deadline": deadline,
+	})
+	relative := createReminder(t, "moved", map[string]interface{}{"channel": "in_app", "before": "1h"})
+	at := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	absolute := createReminder(t, "moved", map[string]interface{}{"channel": "in_app", "at": at})
+
+	// The relative reminder was already sent for the old deadline
+	db := database.CreateConnection()
+	db.Where("id = ?", relative.ID).Model(&models.Reminder{}).Updates(map[string]interface{}{
+		"status":  models.ReminderSent,
+		"sent_at": time.Now(),
+	})
+
+	newDeadline := deadline.Add(72 * time.Hour)
+	rr := handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/moved", map[string]interface{}{
+		"title":    "Dentist",
+		"deadline": newDeadline,
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var rearmed, unchanged models.Reminder
+	db.First(&rearmed, relative.ID)
+	db.First(&unchanged, absolute.ID)
+	if rearmed.Status != models.ReminderPending || rearmed.SentAt != nil || !rearmed.FireAt.Equal(newDeadline.Add(-time.Hour)) {
+		t.Errorf("expected the relative reminder to be re-armed for the new deadline, got %+v", rearmed)
+	}
+	if !unchanged.FireAt.Equal(at) {
+		t.Errorf("expected the absolute reminder to keep its time, got %v", unchanged.FireAt)
+	}
+}
+
+func TestAllDayTaskReminders(t *testing.T) {
+	setupTest(t)
+
+	rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{
+		"id":       "birthday",
+		"title":    "Call grandma",
+		"all_day":  true,
+		"due_date": "2030-01-10",
+	})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	// All-day tasks are due when their day ends in the user's time zone
+	rr = handlerRequest(t, CreateReminder, http.MethodPost, "/v1/tasks/birthday/reminders?tz=Europe/Berlin", map[string]interface{}{"channel": "in_app", "before": "1h"})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var reminder models.Reminder
+	if err := json.NewDecoder(rr.Body).Decode(&reminder); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2030, time.January, 10, 22, 0, 0, 0, time.UTC); !reminder.FireAt.Equal(want) {
+		t.Errorf("expected the reminder an hour before the end of the day in Berlin at %v, got %v", want, reminder.FireAt)
+	}
+
+	rr = handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/birthday", map[string]interface{}{
+		"title":    "Call grandma",
+		"all_day":  true,
+		"due_date": "2030-01-12",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var rearmed models.Reminder
+	database.CreateConnection().First(&rearmed, reminder.ID)
+	if want := time.Date(2030, time.January, 12, 23, 0, 0, 0, time.UTC); rearmed.Status != models.ReminderPending || !rearmed.FireAt.Equal(want) {
+		t.Errorf("expected the reminder to follow the new due date to %v, got %+v", want, rearmed)
+	}
+}
+
+func TestDeleteReminder(t *testing.T) {
+	setupTest(t)
+
+	handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{
+		"id":       "forget",
+		"title":    "Forget me",
+		"deadline": time.Now().Add(24 * time.Hour),
+	})
+	reminder := createReminder(t, "forget", map[string]interface{}{"channel": "email", "before": "10m"})
+
+	path := fmt.Sprintf("/v1/reminders/%d", reminder.ID)
+	if rr := handlerRequest(t, DeleteReminder, http.MethodDelete, path, nil); rr.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if rr := handlerRequest(t, DeleteReminder, http.MethodDelete, path, nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d deleting twice, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	setupTest(t)
+
+	db := database.CreateConnection()
+	for _, title := range []string{"Reminder: First", "Reminder: Second"} {
+		if err := db.Create(&models.Notification{Title: title, Body: "Due soon"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unread := func() []models.Notification {
+		rr := handlerRequest(t, GetNotifications, http.MethodGet, "/v1/notifications?unread=true", nil)
+		var response models.NotificationsResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		return response.Notifications
+	}
+
+	notifications := unread()
+	if len(notifications) != 2 || notifications[0].Title != "Reminder: Second" {
+		t.Fatalf("expected 2 unread notifications, newest first, got %+v", notifications)
+	}
+
+	path := fmt.Sprintf("/v1/notifications/%d/read", notifications[0].ID)
+	rr := handlerRequest(t, MarkNotificationRead, http.MethodPost, path, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if notifications := unread(); len(notifications) != 1 || notifications[0].Title != "Reminder: First" {
+		t.Errorf("expected only the first notification to be unread, got %+v", notifications)
+	}
+
+	if rr := handlerRequest(t, MarkNotificationRead, http.MethodPost, "/v1/notifications/999/read", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/database"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"just-do-it-api/search"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchTasks finds the tasks of the user whose title or description contain
+// the words of the q parameter, best matches first
+func SearchTasks(w http.ResponseWriter, r *http.Request) {
+	terms, err := search.Terms(r.URL.Query().Get("q"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Search for at least one word",
+		))
+		return
+	}
+
+	limit := defaultSearchLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Invalid request",
+				"limit must be between 1 and "+strconv.Itoa(maxSearchLimit),
+			))
+			return
+		}
+	}
+
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	// The search queries are raw SQL and exclude deleted tasks themselves
+	userID := middleware.GetUserID(r)
+	hits, err := search.Tasks(db.Unscoped(), userID, terms, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to search tasks",
+		))
+		return
+	}
+
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.TaskID
+	}
+	var tasks []models.Task
+	if len(ids) > 0 {
+		if err := db.Where("user_id = ? AND id IN ?", userID, ids).Preload("Tags").Find(&tasks).Error; err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Internal server error",
+				"Failed to search tasks",
+			))
+			return
+		}
+	}
+	localize(loc, tasks)
+
+	byID := make(map[string]models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	results := []models.SearchResult{}
+	for _, hit := range hits {
+		task, ok := byID[hit.TaskID]
+		if !ok {
+			continue
+		}
+		results = append(results, models.SearchResult{
+			Task:    task,
+			Rank:    hit.Rank,
+			Title:   hit.Title,
+			Snippet: hit.Snippet,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SearchResponse{Results: results})
+}
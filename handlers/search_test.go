@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"just-do-it-api/search"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func searchTasks(t *testing.T, q string, userID uint) []models.SearchResult {
+	t.Helper()
+
+	rr := zonedRequest(t, SearchTasks, "/v1/tasks/search?"+url.Values{"q": {q}}.Encode(), userID, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response models.SearchResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return response.Results
+}
+
+// createSearchTasks adds the tasks the search tests look for, returning their owner
+func createSearchTasks(t *testing.T) models.User {
+	t.Helper()
+	db := database.CreateConnection()
+
+	owner := models.User{Email: "search@example.com", Password: "hash"}
+	other := models.User{Email: "other-search@example.com", Password: "hash"}
+	for _, user := range []*models.User{&owner, &other} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tasks := []models.Task{
+		{ID: "invoice", UserID: owner.ID, Title: "Send invoice", Description: "Attach the receipts from the conference trip"},
+		{ID: "receipts", UserID: owner.ID, Title: "Scan receipts", Description: "Everything in the shoebox"},
+		{ID: "taxes", UserID: owner.ID, Title: "Taxes", Description: "Collect the invoices of the year"},
+		{ID: "markup", UserID: owner.ID, Title: "Escape <script> tags", Description: "Check that a < b & c > d still compares"},
+		{ID: "foreign", UserID: other.ID, Title: "Receipts of someone else"},
+	}
+	for _, task := range tasks {
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return owner
+}
+
+func searchIDs(t *testing.T, q string, userID uint) string {
+	t.Helper()
+
+	ids := []string{}
+	for _, result := range searchTasks(t, q, userID) {
+		ids = append(ids, result.Task.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+// testSearchRanking checks the order and highlights of the search engine the
+// mock database has
+func testSearchRanking(t *testing.T, owner models.User) {
+	t.Helper()
+
+	if got := searchIDs(t, "receipts", owner.ID); got != "receipts,invoice" {
+		t.Errorf("expected title matches before description matches, got %q", got)
+	}
+
+	results := searchTasks(t, "conference", owner.ID)
+	if len(results) != 1 {
+		t.Fatalf("expected one result, got %d", len(results))
+	}
+	if !strings.Contains(results[0].Snippet, "<mark>conference</mark>") {
+		t.Errorf("expected the match to be marked in the snippet, got %q", results[0].Snippet)
+	}
+	results = searchTasks(t, "scan", owner.ID)
+	if len(results) != 1 || results[0].Title != "<mark>Scan</mark> receipts" {
+		t.Errorf("expected the match to be marked in the title, got %+v", results)
+	}
+
+	// Only the marks are markup, the text of the task is escaped
+	results = searchTasks(t, "escape", owner.ID)
+	if len(results) != 1 || results[0].Title != "<mark>Escape</mark> &lt;script&gt; tags" {
+		t.Errorf("expected the title to be escaped, got %+v", results)
+	}
+	results = searchTasks(t, "compares", owner.ID)
+	if len(results) != 1 || !strings.Contains(results[0].Snippet, "a &lt; b &amp; c &gt; d still <mark>compares</mark>") {
+		t.Errorf("expected the snippet to be escaped, got %+v", results)
+	}
+}
+
+func TestSearchTasks(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+	owner := createSearchTasks(t)
+
+	tests := []struct {
+		name     string
+		q        string
+		expected string
+	}{
+		{"Every Word", "receipts conference", "invoice"},
+		{"Prefix Of The Last Word", "scan rece", "receipts"},
+		{"Only The User's Tasks", "someone", ""},
+		{"No Match", "groceries", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchIDs(t, tt.q, owner.ID); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	// Edits and deletions are searchable right away
+	if err := db.Where("id = ?", "taxes").Model(&models.Task{}).Update("title", "Tax return").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIDs(t, "return", owner.ID); got != "taxes" {
+		t.Errorf("expected the renamed task to be found, got %q", got)
+	}
+	if err := db.Delete(&models.Task{}, "id = ?", "receipts").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIDs(t, "scan", owner.ID); got != "" {
+		t.Errorf("expected deleted tasks not to be found, got %q", got)
+	}
+
+	for _, q := range []string{"", "  ?! "} {
+		rr := zonedRequest(t, SearchTasks, "/v1/tasks/search?"+url.Values{"q": {q}}.Encode(), owner.ID, "")
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for %q, got %d", http.StatusBadRequest, q, rr.Code)
+		}
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	setupTest(t)
+	if engine := search.Engine(database.CreateConnection().Unscoped()); engine != search.EngineFTS5 {
+		t.Skipf("SQLite searches with %s, run the tests with -tags sqlite_fts5 to rank with FTS5", engine)
+	}
+
+	testSearchRanking(t, createSearchTasks(t))
+}
+
+func TestSearchFallback(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	// Without the index search scans the tasks with LIKE
+	for _, statement := range []string{
+		"DROP TRIGGER IF EXISTS tasks_fts_insert",
+		"DROP TRIGGER IF EXISTS tasks_fts_delete",
+		"DROP TRIGGER IF EXISTS tasks_fts_update",
+		"DROP TABLE IF EXISTS " + database.SearchIndexTable,
+	} {
+		if err := db.Unscoped().Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if engine := search.Engine(db.Unscoped()); engine != search.EngineLike {
+		t.Fatalf("expected the LIKE fallback, got %s", engine)
+	}
+
+	testSearchRanking(t, createSearchTasks(t))
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"just-do-it-api/auth"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// newAuthResponse starts a new session for the user and issues its access and refresh tokens
+func newAuthResponse(r *http.Request, user *models.User) (models.AuthResponse, error) {
+	session, refreshToken, err := auth.CreateSession(db, user.ID, r.UserAgent(), middleware.ClientIP(r))
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	return authResponseForSession(user, session, refreshToken)
+}
+
+func authResponseForSession(user *models.User, session *models.Session, refreshToken string) (models.AuthResponse, error) {
+	token, err := auth.GenerateToken(user, session.ID)
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	return models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(auth.AccessTokenTTL / time.Second),
+		User:         *user,
+	}, nil
+}
+
+func Refresh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Failed to parse request body",
+		))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Validation error",
+			err.Error(),
+		))
+		return
+	}
+
+	session, refreshToken, err := auth.RotateRefreshToken(db, req.RefreshToken)
+	if errors.Is(err, auth.ErrRefreshTokenReused) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Refresh failed",
+			"Refresh token was already used, the session has been revoked",
+		))
+		return
+	}
+	if errors.Is(err, auth.ErrInvalidRefreshToken) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Refresh failed",
+			"Invalid or expired refresh token",
+		))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Refresh failed",
+			"Failed to rotate refresh token",
+		))
+		return
+	}
+
+	var user models.User
+	if err := db.First(&user, session.UserID).Error; err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Refresh failed",
+			"User no longer exists",
+		))
+		return
+	}
+
+	if !checkAccountActive(w, &user, "Refresh failed") {
+		return
+	}
+
+	response, err := authResponseForSession(&user, session, refreshToken)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Refresh failed",
+			"Failed to generate token",
+		))
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func Logout(w http.ResponseWriter, r *http.Request) {
+	if err := auth.RevokeSession(db, middleware.GetSessionID(r)); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to end session",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetSessions(w http.ResponseWriter, r *http.Request) {
+	var sessions []models.Session
+
+	userID := middleware.GetUserID(r)
+	result := db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).Order("last_used_at DESC").Find(&sessions)
+	if result.Error != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch sessions",
+		))
+		return
+	}
+
+	currentID := middleware.GetSessionID(r)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SessionsResponse{Sessions: sessions})
+}
+
+func RevokeSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := strings.TrimPrefix(r.URL.Path, "/api/auth/sessions/")
+	if sessionID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Session ID is required",
+		))
+		return
+	}
+
+	var session models.Session
+	userID := middleware.GetUserID(r)
+	if err := db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Session not found",
+		))
+		return
+	}
+
+	if err := auth.RevokeSession(db, session.ID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to revoke session",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOtherSessions logs out every device of the user except the one making the request
+func RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if err := auth.RevokeUserSessions(db, userID, middleware.GetSessionID(r)); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to revoke sessions",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
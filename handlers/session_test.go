@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// loginTestUser creates a user in the auth mock database and logs it in
+func loginTestUser(t *testing.T, email string) models.AuthResponse {
+	testUser := &models.User{
+		Email:    email,
+		Password: "password123",
+	}
+	testUser.HashPassword()
+	if err := db.Create(testUser).Error; err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	payloadBytes, _ := json.Marshal(models.LoginRequest{Email: email, Password: "password123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewReader(payloadBytes))
+	w := httptest.NewRecorder()
+
+	Login(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("login failed with status %d: %s", w.Code, w.Body.String())
+	}
+
+	var response models.AuthResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func refresh(refreshToken string) *httptest.ResponseRecorder {
+	payloadBytes, _ := json.Marshal(models.RefreshRequest{RefreshToken: refreshToken})
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewReader(payloadBytes))
+	w := httptest.NewRecorder()
+
+	Refresh(w, req)
+	return w
+}
+
+// withSession returns the request with the user and session IDs set as AuthMiddleware would
+func withSession(req *http.Request, userID uint, sessionID string) *http.Request {
+	ctx := context.WithValue(req.Context(), middleware.UserIDKey, userID)
+	ctx = context.WithValue(ctx, middleware.SessionIDKey, sessionID)
+	return req.WithContext(ctx)
+}
+
+func TestRefresh(t *testing.T) {
+	db = NewAuthMockDB()
+
+	login := loginTestUser(t, "test@example.com")
+	if login.RefreshToken == "" {
+		t.Fatal("expected refresh token in login response")
+	}
+
+	// First refresh rotates the token
+	w := refresh(login.RefreshToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var rotated models.AuthResponse
+	json.NewDecoder(w.Body).Decode(&rotated)
+	if rotated.Token == "" || rotated.RefreshToken == "" {
+		t.Fatal("expected new token pair in refresh response")
+	}
+	if rotated.RefreshToken == login.RefreshToken {
+		t.Error("expected refresh token to be rotated")
+	}
+
+	// Reusing the old token is detected and kills the session
+	if w := refresh(login.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for reused token, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := refresh(rotated.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d after session revocation, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// Unknown tokens are rejected
+	if w := refresh("not-a-token"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for unknown token, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	db = NewAuthMockDB()
+
+	login := loginTestUser(t, "test@example.com")
+
+	var session models.Session
+	if err := db.Where("user_id = ?", login.User.ID).First(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	req := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil), login.User.ID, session.ID)
+	w := httptest.NewRecorder()
+
+	Logout(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	if w := refresh(login.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected refresh to fail after logout, got %d", w.Code)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	db = NewAuthMockDB()
+
+	first := loginTestUser(t, "test@example.com")
+
+	// Log in a second time from another device
+	payloadBytes, _ := json.Marshal(models.LoginRequest{Email: "test@example.com", Password: "password123"})
+	loginReq := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewReader(payloadBytes))
+	loginW := httptest.NewRecorder()
+	Login(loginW, loginReq)
+
+	var sessions []models.Session
+	db.Where("user_id = ?", first.User.ID).Order("created_at").Find(&sessions)
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+	current, other := sessions[0], sessions[1]
+
+	// List sessions
+	req := withSession(httptest.NewRequest(http.MethodGet, "/api/auth/sessions", nil), first.User.ID, current.ID)
+	w := httptest.NewRecorder()
+	GetSessions(w, req)
+
+	var listed models.SessionsResponse
+	json.NewDecoder(w.Body).Decode(&listed)
+	if len(listed.Sessions) != 2 {
+		t.Fatalf("expected 2 sessions in response, got %d", len(listed.Sessions))
+	}
+	for _, s := range listed.Sessions {
+		if s.Current != (s.ID == current.ID) {
+			t.Errorf("session %s has wrong current flag", s.ID)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		sessionID      string
+		expectedStatus int
+	}{
+		{
+			name:           "Revoke Other Device",
+			sessionID:      other.ID,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Already Revoked",
+			sessionID:      other.ID,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Unknown Session",
+			sessionID:      "unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withSession(httptest.NewRequest(http.MethodDelete, "/api/auth/sessions/"+tt.sessionID, nil), first.User.ID, current.ID)
+			w := httptest.NewRecorder()
+
+			RevokeSession(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
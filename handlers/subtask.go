@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"just-do-it-api/config"
+	"just-do-it-api/database"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"strings"
+)
+
+// Rules for completing a task that has subtasks
+const (
+	CompletionCascade = "cascade" // completing a task completes all of its subtasks
+	CompletionBlock   = "block"   // a task can't be completed while subtasks are open
+)
+
+var (
+	// maxTaskDepth is how many levels a task hierarchy may have, top-level tasks included
+	maxTaskDepth = config.Int("TASK_MAX_DEPTH", 5)
+
+	// completionMode is the rule for completing tasks with subtasks
+	completionMode = config.String("TASK_COMPLETION_MODE", CompletionBlock)
+)
+
+// Errors of checkParent caused by the request rather than the database
+var (
+	errParentNotFound = errors.New("parent task not found")
+	errParentCycle    = errors.New("a task can't be a subtask of itself or of its own subtasks")
+	errTooDeep        = errors.New("subtasks are nested too deeply")
+)
+
+// ancestorIDs returns the IDs of the parent, grandparent and so on of a task,
+// nearest first. It gives up after maxTaskDepth levels, which is only
+// reached when the hierarchy is broken.
+func ancestorIDs(db database.Database, parentID *string) ([]string, error) {
+	var ids []string
+	for parentID != nil && len(ids) <= maxTaskDepth {
+		var parent models.Task
+		if err := db.Where("id = ?", *parentID).Select("id", "parent_id").First(&parent).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, parent.ID)
+		parentID = parent.ParentID
+	}
+	return ids, nil
+}
+
+// descendantLevels returns the IDs of the subtasks of a task level by level
+func descendantLevels(db database.Database, id string) ([][]string, error) {
+	var levels [][]string
+	current := []string{id}
+	for len(current) > 0 && len(levels) <= maxTaskDepth {
+		var children []string
+		if err := db.Where("parent_id IN ?", current).Model(&models.Task{}).Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		if len(children) > 0 {
+			levels = append(levels, children)
+		}
+		current = children
+	}
+	return levels, nil
+}
+
+// descendantIDs returns the IDs of all subtasks of a task
+func descendantIDs(db database.Database, id string) ([]string, error) {
+	levels, err := descendantLevels(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, level := range levels {
+		ids = append(ids, level...)
+	}
+	return ids, nil
+}
+
+// checkParent makes sure task can be placed below its ParentID: the parent
+// has to be a task of the same user, must not be the task itself or one of
+// its subtasks, and the hierarchy must stay within maxTaskDepth levels
+func checkParent(db database.Database, userID uint, task *models.Task) error {
+	if task.ParentID == nil {
+		return nil
+	}
+
+	var count int64
+	if err := db.Where("id = ? AND user_id = ?", *task.ParentID, userID).Model(&models.Task{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errParentNotFound
+	}
+
+	ancestors, err := ancestorIDs(db, task.ParentID)
+	if err != nil {
+		return err
+	}
+
+	subtreeDepth := 1
+	if task.ID != "" {
+		for _, id := range ancestors {
+			if id == task.ID {
+				return errParentCycle
+			}
+		}
+
+		levels, err := descendantLevels(db, task.ID)
+		if err != nil {
+			return err
+		}
+		subtreeDepth += len(levels)
+	}
+
+	if len(ancestors)+subtreeDepth > maxTaskDepth {
+		return errTooDeep
+	}
+	return nil
+}
+
+// writeParentError answers a failed checkParent
+func writeParentError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errParentNotFound) || errors.Is(err, errParentCycle) || errors.Is(err, errTooDeep) {
+		message := err.Error()
+		if errors.Is(err, errTooDeep) {
+			message = fmt.Sprintf("Tasks can be nested at most %d levels deep", maxTaskDepth)
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			message,
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(models.NewErrorResponse(
+		"Internal server error",
+		"Failed to check parent task",
+	))
+}
+
+// withProgress sets the progress of the tasks that have subtasks
+func withProgress(db database.Database, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+
+	var counts []struct {
+		ParentID  string
+		Total     int64
+		Completed int64
+	}
+	err := db.Where("parent_id IN ?", ids).Model(&models.Task{}).
+		Select("parent_id, COUNT(*) AS total, SUM(CASE WHEN completed THEN 1 ELSE 0 END) AS completed").
+		Group("parent_id").
+		Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+
+	for _, count := range counts {
+		for i := range tasks {
+			if tasks[i].ID == count.ParentID {
+				tasks[i].Progress = models.NewTaskProgress(count.Completed, count.Total)
+			}
+		}
+	}
+	return nil
+}
+
+// GetSubtasks lists the direct subtasks of a task
+func GetSubtasks(w http.ResponseWriter, r *http.Request) {
+	taskID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/tasks/"), "/subtasks")
+
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserID(r)
+	var parent models.Task
+	if err := db.Where("id = ? AND user_id = ?", taskID, userID).First(&parent).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Task not found",
+		))
+		return
+	}
+
+	query, ok := taskFilter(w, r, db, db.Where("parent_id = ?", parent.ID), loc)
+	if !ok {
+		return
+	}
+
+	var tasks []models.Task
+	err := query.Order(dueColumn).Preload("Tags").Find(&tasks).Error
+	if err == nil {
+		err = withProgress(db, tasks)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch subtasks",
+		))
+		return
+	}
+
+	localize(loc, tasks)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TaskResponse{Tasks: tasks})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func createSubtask(t *testing.T, id string, parentID string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload := map[string]interface{}{
+		"id":       id,
+		"title":    "Task " + id,
+		"deadline": time.Now().Add(24 * time.Hour),
+	}
+	if parentID != "" {
+		payload["parent_id"] = parentID
+	}
+
+	return handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", payload)
+}
+
+func mustCreateSubtask(t *testing.T, id string, parentID string) {
+	t.Helper()
+
+	if rr := createSubtask(t, id, parentID); rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d creating %s, got %d: %s", http.StatusCreated, id, rr.Code, rr.Body.String())
+	}
+}
+
+func toggle(t *testing.T, id string) int {
+	t.Helper()
+	return handlerRequest(t, ToggleTask, http.MethodPatch, "/v1/tasks/"+id+"/toggle", nil).Code
+}
+
+func TestSubtaskProgress(t *testing.T) {
+	setupTest(t)
+
+	mustCreateSubtask(t, "parent", "")
+	mustCreateSubtask(t, "step-1", "parent")
+	mustCreateSubtask(t, "step-2", "parent")
+	mustCreateSubtask(t, "step-3", "parent")
+	if code := toggle(t, "step-1"); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+
+	rr := handlerRequest(t, GetSubtasks, http.MethodGet, "/v1/tasks/parent/subtasks", nil)
+	var subtasks TaskResponse
+	if err := json.NewDecoder(rr.Body).Decode(&subtasks); err != nil {
+		t.Fatal(err)
+	}
+	if len(subtasks.Tasks) != 3 {
+		t.Errorf("expected 3 subtasks, got %d", len(subtasks.Tasks))
+	}
+
+	rr = handlerRequest(t, GetTasks, http.MethodGet, "/v1/tasks", nil)
+	var response TaskResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range response.Tasks {
+		switch task.ID {
+		case "parent":
+			if task.Progress == nil || task.Progress.Summary != "1/3 subtasks done" {
+				t.Errorf("unexpected progress of the parent: %+v", task.Progress)
+			}
+		default:
+			if task.Progress != nil {
+				t.Errorf("expected no progress for task %s without subtasks, got %+v", task.ID, task.Progress)
+			}
+		}
+	}
+}
+
+func TestSubtaskHierarchyRules(t *testing.T) {
+	setupTest(t)
+
+	defer func(depth int) { maxTaskDepth = depth }(maxTaskDepth)
+	maxTaskDepth = 3
+
+	mustCreateSubtask(t, "a", "")
+	mustCreateSubtask(t, "b", "a")
+	mustCreateSubtask(t, "c", "b")
+	mustCreateSubtask(t, "x", "")
+
+	if rr := createSubtask(t, "d", "c"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a fourth level, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if rr := createSubtask(t, "e", "unknown"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown parent, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	tests := []struct {
+		name         string
+		taskID       string
+		parentID     string
+		expectedCode int
+	}{
+		{"Parent Of Itself", "a", "a", http.StatusBadRequest},
+		{"Below Own Subtask", "a", "c", http.StatusBadRequest},
+		{"Subtree Too Deep", "a", "x", http.StatusBadRequest},
+		{"Move Up", "c", "a", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/"+tt.taskID, map[string]interface{}{
+				"title":     "Task " + tt.taskID,
+				"deadline":  time.Now().Add(24 * time.Hour),
+				"parent_id": tt.parentID,
+			})
+			if rr.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", rr.Code, tt.expectedCode, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCompletionBlockedByOpenSubtasks(t *testing.T) {
+	setupTest(t)
+
+	defer func(mode string) { completionMode = mode }(completionMode)
+	completionMode = CompletionBlock
+
+	mustCreateSubtask(t, "parent", "")
+	mustCreateSubtask(t, "child", "parent")
+	mustCreateSubtask(t, "grandchild", "child")
+
+	if code := toggle(t, "parent"); code != http.StatusConflict {
+		t.Fatalf("expected status %d while subtasks are open, got %d", http.StatusConflict, code)
+	}
+
+	for _, id := range []string{"grandchild", "child", "parent"} {
+		if code := toggle(t, id); code != http.StatusOK {
+			t.Fatalf("expected status %d completing %s, got %d", http.StatusOK, id, code)
+		}
+	}
+
+	// Reopening a subtask reopens the tasks above it
+	if code := toggle(t, "grandchild"); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	for _, id := range []string{"child", "parent"} {
+		if loadTask(t, id).Completed {
+			t.Errorf("expected %s to be reopened", id)
+		}
+	}
+}
+
+func TestCompletionCascadesToSubtasks(t *testing.T) {
+	setupTest(t)
+
+	defer func(mode string) { completionMode = mode }(completionMode)
+	completionMode = CompletionCascade
+
+	mustCreateSubtask(t, "parent", "")
+	mustCreateSubtask(t, "child", "parent")
+	mustCreateSubtask(t, "grandchild", "child")
+
+	if code := toggle(t, "parent"); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	for _, id := range []string{"parent", "child", "grandchild"} {
+		if !loadTask(t, id).Completed {
+			t.Errorf("expected %s to be completed", id)
+		}
+	}
+}
+
+func TestDeleteTaskDeletesSubtree(t *testing.T) {
+	setupTest(t)
+
+	mustCreateSubtask(t, "parent", "")
+	mustCreateSubtask(t, "child", "parent")
+	mustCreateSubtask(t, "grandchild", "child")
+	mustCreateSubtask(t, "other", "")
+
+	rr := handlerRequest(t, DeleteTask, http.MethodDelete, "/v1/tasks/parent", nil)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+
+	for _, id := range []string{"parent", "child", "grandchild"} {
+		if !loadTask(t, id).DeletedAt.Valid {
+			t.Errorf("expected %s to be deleted", id)
+		}
+	}
+	if loadTask(t, "other").DeletedAt.Valid {
+		t.Error("expected unrelated tasks to be kept")
+	}
+}
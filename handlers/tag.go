@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"just-do-it-api/database"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Modes for filtering tasks by several tags
+const (
+	TagMatchAny = "any" // tasks with at least one of the tags
+	TagMatchAll = "all" // tasks with every one of the tags
+)
+
+// Errors of resolveTags caused by the request rather than the database
+var (
+	errTagNotFound = errors.New("tag not found")
+	errInvalidTag  = errors.New("invalid tag")
+)
+
+// userTag loads the tag addressed by /v1/tags/{id}[/action]
+func userTag(w http.ResponseWriter, r *http.Request, db database.Database) (*models.Tag, bool) {
+	tagID, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/tags/"), "/")
+
+	var tag models.Tag
+	if err := db.Where("id = ? AND user_id = ?", tagID, middleware.GetUserID(r)).First(&tag).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Tag not found",
+		))
+		return nil, false
+	}
+	return &tag, true
+}
+
+// tagNameTaken reports whether the user has another tag with this name
+func tagNameTaken(db database.Database, userID uint, name string, exceptID uint) (bool, error) {
+	var count int64
+	err := db.Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).Model(&models.Tag{}).Count(&count).Error
+	return count > 0, err
+}
+
+// resolveTags turns the tags of a task request into stored tags of the user.
+// Tags given by ID must exist, tags given by name are created on first use.
+func resolveTags(db database.Database, userID uint, tags []models.Tag) ([]models.Tag, error) {
+	resolved := []models.Tag{}
+	seen := map[uint]bool{}
+
+	for _, requested := range tags {
+		var tag models.Tag
+		if requested.ID != 0 {
+			if err := db.Where("id = ? AND user_id = ?", requested.ID, userID).First(&tag).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, errTagNotFound
+				}
+				return nil, err
+			}
+		} else {
+			requested.Name = strings.TrimSpace(requested.Name)
+			if err := validate.Struct(models.TagRequest{Name: requested.Name}); err != nil {
+				return nil, fmt.Errorf("%w %q: %v", errInvalidTag, requested.Name, err)
+			}
+
+			err := db.Where("user_id = ? AND name = ?", userID, requested.Name).First(&tag).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				tag = models.Tag{UserID: userID, Name: requested.Name}
+				err = db.Create(&tag).Error
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			resolved = append(resolved, tag)
+		}
+	}
+
+	return resolved, nil
+}
+
+// writeTagError answers a failed resolveTags
+func writeTagError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errTagNotFound) || errors.Is(err, errInvalidTag) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			err.Error(),
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(models.NewErrorResponse(
+		"Internal server error",
+		"Failed to save tags",
+	))
+}
+
+// tagNames collects the tag names of repeated and comma separated tag parameters
+func tagNames(values []string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// filterByTags restricts a task query to tasks with any or all of the named tags
+func filterByTags(db database.Database, query *gorm.DB, userID uint, names []string, mode string) *gorm.DB {
+	tagged := db.Where("tags.user_id = ? AND tags.name IN ?", userID, names).
+		Table("task_tags").
+		Select("task_tags.task_id").
+		Joins("JOIN tags ON tags.id = task_tags.tag_id")
+
+	if mode == TagMatchAll {
+		tagged = tagged.Group("task_tags.task_id").Having("COUNT(DISTINCT tags.id) = ?", len(names))
+	}
+
+	return query.Where("id IN (?)", tagged)
+}
+
+func GetTags(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	var tags []models.Tag
+
+	userID := middleware.GetUserID(r)
+	if err := db.Where("user_id = ?", userID).Order("name").Find(&tags).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch tags",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.TagsResponse{Tags: tags})
+}
+
+func GetTag(w http.ResponseWriter, r *http.Request) {
+	tag, ok := userTag(w, r, database.CreateConnection())
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+func CreateTag(w http.ResponseWriter, r *http.Request) {
+	var req models.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Invalid JSON format",
+		))
+		return
+	}
+	defer r.Body.Close()
+
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			err.Error(),
+		))
+		return
+	}
+
+	db := database.CreateConnection()
+	userID := middleware.GetUserID(r)
+	taken, err := tagNameTaken(db, userID, req.Name, 0)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to create tag",
+		))
+		return
+	}
+	if taken {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Conflict",
+			"A tag with this name already exists",
+		))
+		return
+	}
+
+	tag := models.Tag{UserID: userID, Name: req.Name, Color: req.Color}
+	if err := db.Create(&tag).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to create tag",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+// UpdateTag renames or recolors a tag. Tasks reference tags by ID, so every
+// task with the tag shows the new name right away.
+func UpdateTag(w http.ResponseWriter, r *http.Request) {
+	var req models.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Invalid JSON format",
+		))
+		return
+	}
+	defer r.Body.Close()
+
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			err.Error(),
+		))
+		return
+	}
+
+	db := database.CreateConnection()
+	tag, ok := userTag(w, r, db)
+	if !ok {
+		return
+	}
+
+	taken, err := tagNameTaken(db, tag.UserID, req.Name, tag.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to update tag",
+		))
+		return
+	}
+	if taken {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Conflict",
+			"A tag with this name already exists, merge the tags instead",
+		))
+		return
+	}
+
+	tag.Name = req.Name
+	tag.Color = req.Color
+	if err := db.Save(tag).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to update tag",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// DeleteTag removes the tag from all tasks and deletes it
+func DeleteTag(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	tag, ok := userTag(w, r, db)
+	if !ok {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM task_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to delete tag",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MergeTag moves every task from the tag onto the target tag and deletes it
+func MergeTag(w http.ResponseWriter, r *http.Request) {
+	var req models.MergeTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Invalid JSON format",
+		))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validate.Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			err.Error(),
+		))
+		return
+	}
+
+	db := database.CreateConnection()
+	source, ok := userTag(w, r, db)
+	if !ok {
+		return
+	}
+
+	var target models.Tag
+	if err := db.Where("id = ? AND user_id = ?", req.TargetID, source.UserID).First(&target).Error; err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Target tag not found",
+		))
+		return
+	}
+	if target.ID == source.ID {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"A tag can't be merged into itself",
+		))
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO task_tags (task_id, tag_id)
+			SELECT task_id, ? FROM task_tags
+			WHERE tag_id = ? AND task_id NOT IN (SELECT task_id FROM task_tags WHERE tag_id = ?)`,
+			target.ID, source.ID, target.ID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM task_tags WHERE tag_id = ?", source.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(source).Error
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to merge tags",
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(target)
+}
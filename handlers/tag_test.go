@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"just-do-it-api/models"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func taskTagNames(t *testing.T, taskID string) []string {
+	t.Helper()
+
+	rr := handlerRequest(t, GetTasks, http.MethodGet, "/v1/tasks", nil)
+	var response TaskResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, task := range response.Tasks {
+		if task.ID == taskID {
+			names := []string{}
+			for _, tag := range task.Tags {
+				names = append(names, tag.Name)
+			}
+			sort.Strings(names)
+			return names
+		}
+	}
+	t.Fatalf("task %s not found", taskID)
+	return nil
+}
+
+func TestCreateTag(t *testing.T) {
+	setupTest(t)
+
+	tests := []struct {
+		name         string
+		payload      models.TagRequest
+		expectedCode int
+	}{
+		{"Valid Tag", models.TagRequest{Name: "client-a", Color: "#ff8800"}, http.StatusCreated},
+		{"Duplicate Name", models.TagRequest{Name: "client-a"}, http.StatusConflict},
+		{"Missing Name", models.TagRequest{Name: "  "}, http.StatusBadRequest},
+		{"Invalid Color", models.TagRequest{Name: "errand", Color: "orange"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := handlerRequest(t, CreateTag, http.MethodPost, "/v1/tags", tt.payload)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedCode)
+			}
+		})
+	}
+
+	rr := handlerRequest(t, GetTags, http.MethodGet, "/v1/tags", nil)
+	var response models.TagsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Tags) != 1 || response.Tags[0].Color != "#ff8800" {
+		t.Errorf("expected the one created tag, got %+v", response.Tags)
+	}
+}
+
+func TestTaskTags(t *testing.T) {
+	setupTest(t)
+
+	task := createTaggedTask(t, "tagged", "errand", "client-a", "errand")
+	if len(task.Tags) != 2 {
+		t.Fatalf("expected duplicate tags to be dropped, got %+v", task.Tags)
+	}
+
+	// Tags are kept when an update doesn't mention them
+	rr := handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/tagged", map[string]interface{}{
+		"title":    "Renamed",
+		"deadline": time.Now().Add(24 * time.Hour),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if names := taskTagNames(t, "tagged"); strings.Join(names, ",") != "client-a,errand" {
+		t.Errorf("expected the tags to be kept, got %v", names)
+	}
+
+	// and replaced when it does, by name or by ID
+	rr = handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/tagged", map[string]interface{}{
+		"title":    "Renamed",
+		"deadline": time.Now().Add(24 * time.Hour),
+		"tags":     []interface{}{"home", map[string]interface{}{"id": task.Tags[0].ID}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	expected := []string{"home", task.Tags[0].Name}
+	sort.Strings(expected)
+	if names := taskTagNames(t, "tagged"); strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected the tags to be replaced, got %v", names)
+	}
+
+	rr = handlerRequest(t, UpdateTask, http.MethodPut, "/v1/tasks/tagged", map[string]interface{}{
+		"title":    "Renamed",
+		"deadline": time.Now().Add(24 * time.Hour),
+		"tags":     []interface{}{map[string]interface{}{"id": 999}},
+	})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown tag, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestGetTasksByTag(t *testing.T) {
+	setupTest(t)
+
+	createTaggedTask(t, "a", "client-a")
+	createTaggedTask(t, "ab", "client-a", "errand")
+	createTaggedTask(t, "b", "errand")
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"tag=client-a", "a,ab"},
+		{"tag=client-a&tag=errand", "a,ab,b"},
+		{"tag=client-a,errand&tag_mode=any", "a,ab,b"},
+		{"tag=client-a&tag=errand&tag_mode=all", "ab"},
+		{"tag=unknown", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := taskIDs(t, GetTasks, "/v1/tasks?"+tt.query); got != tt.expected {
+				t.Errorf("got %v want %v", got, tt.expected)
+			}
+		})
+	}
+
+	rr := handlerRequest(t, GetTasks, http.MethodGet, "/v1/tasks?tag=errand&tag_mode=some", nil)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an invalid tag mode, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestRenameAndMergeTags(t *testing.T) {
+	setupTest(t)
+
+	first := createTaggedTask(t, "first", "client-a")
+	createTaggedTask(t, "both", "client-a", "customer-a")
+	second := createTaggedTask(t, "second", "customer-a")
+	clientA := first.Tags[0]
+	customerA := second.Tags[0]
+
+	// Renaming a tag is visible on all its tasks
+	rr := handlerRequest(t, UpdateTag, http.MethodPut, fmt.Sprintf("/v1/tags/%d", clientA.ID), models.TagRequest{Name: "acme"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if names := taskTagNames(t, "first"); strings.Join(names, ",") != "acme" {
+		t.Errorf("expected the renamed tag on the task, got %v", names)
+	}
+
+	rr = handlerRequest(t, UpdateTag, http.MethodPut, fmt.Sprintf("/v1/tags/%d", clientA.ID), models.TagRequest{Name: "customer-a"})
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected status %d renaming to an existing tag, got %d", http.StatusConflict, rr.Code)
+	}
+
+	// Merging moves the tasks onto the target without duplicates
+	rr = handlerRequest(t, MergeTag, http.MethodPost, fmt.Sprintf("/v1/tags/%d/merge", customerA.ID), models.MergeTagRequest{TargetID: clientA.ID})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	for _, id := range []string{"first", "both", "second"} {
+		if names := taskTagNames(t, id); strings.Join(names, ",") != "acme" {
+			t.Errorf("task %s: expected only the merged tag, got %v", id, names)
+		}
+	}
+
+	rr = handlerRequest(t, GetTag, http.MethodGet, fmt.Sprintf("/v1/tags/%d", customerA.ID), nil)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected the merged tag to be deleted, got status %d", rr.Code)
+	}
+
+	rr = handlerRequest(t, MergeTag, http.MethodPost, fmt.Sprintf("/v1/tags/%d/merge", clientA.ID), models.MergeTagRequest{TargetID: clientA.ID})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d merging a tag into itself, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	// Deleting removes the tag from its tasks
+	rr = handlerRequest(t, DeleteTag, http.MethodDelete, fmt.Sprintf("/v1/tags/%d", clientA.ID), nil)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if names := taskTagNames(t, "both"); len(names) != 0 {
+		t.Errorf("expected no tags left on the task, got %v", names)
+	}
+}
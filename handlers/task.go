@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"just-do-it-api/config"
 	"just-do-it-api/database"
 	"just-do-it-api/middleware"
 	"just-do-it-api/models"
+	"just-do-it-api/reminders"
 	"net/http"
 	"strings"
 	"time"
@@ -12,23 +16,54 @@ import (
 	"gorm.io/gorm"
 )
 
+// urgencyWindow is how close a deadline has to be for a task without explicit urgency to count as urgent
+var urgencyWindow = config.Duration("URGENCY_WINDOW", 48*time.Hour)
+
+// started matches tasks without a start date or whose start date has come
+const started = "(start_date IS NULL OR start_date <= ?)"
+
 type TaskResponse struct {
-	Tasks []models.Task `json:"tasks"`
+	Tasks      []models.Task `json:"tasks"`
+	NextCursor string        `json:"next_cursor,omitempty"` // set when there are more tasks
 }
 
 func GetTasks(w http.ResponseWriter, r *http.Request) {
 	db := database.CreateConnection()
-	var tasks []models.Task
+
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	opts, ok := parseListOptions(w, r, "created_at")
+	if !ok {
+		return
+	}
 
 	userID := middleware.GetUserID(r)
 	deadlineStr := r.URL.Query().Get("deadline")
 
-	var result *gorm.DB
 	query := db.Where("user_id = ?", userID)
 
+	if tags := tagNames(r.URL.Query()["tag"]); len(tags) > 0 {
+		mode := r.URL.Query().Get("tag_mode")
+		if mode == "" {
+			mode = TagMatchAny
+		}
+		if mode != TagMatchAny && mode != TagMatchAll {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Invalid tag mode",
+				"Tag mode must be either any or all",
+			))
+			return
+		}
+		query = filterByTags(db, query, userID, tags, mode)
+	}
+
 	if deadlineStr != "" {
 		layout := "2006-01-02"
-		deadline, err := time.Parse(layout, deadlineStr)
+		day, err := time.ParseInLocation(layout, deadlineStr, loc)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			json.NewEncoder(w).Encode(models.NewErrorResponse(
@@ -38,12 +73,18 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		result = query.Where("DATE(deadline) = ?", deadline.Format("2006-01-02")).Find(&tasks)
-	} else {
-		result = query.Find(&tasks)
+		// Timed deadlines fall on the day from midnight to midnight in the
+		// user's time zone, all-day tasks are due on the date itself
+		query = query.Where("((deadline >= ? AND deadline < ?) OR due_date = ?)", day.UTC(), day.AddDate(0, 0, 1).UTC(), models.DateOf(day))
+	}
+
+	query, ok = taskFilter(w, r, db, query, loc)
+	if !ok {
+		return
 	}
 
-	if result.Error != nil {
+	tasks, next, err := listTasks(db, query, opts)
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
 			"Internal server error",
@@ -52,8 +93,8 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(TaskResponse{Tasks: tasks})
+	localize(loc, tasks)
+	writeTaskList(w, tasks, next, opts)
 }
 
 func CreateTask(w http.ResponseWriter, r *http.Request) {
@@ -81,9 +122,50 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	// Recurring tasks repeat in the user's time zone unless they name another one
+	if task.Recurrence != "" && task.RecurrenceTimezone == "" {
+		task.RecurrenceTimezone = loc.String()
+	}
+	task.Occurrence = 0
+	if err := normalizeRecurrence(&task); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := checkProject(db, userID, task.ProjectID); err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	if err := checkParent(db, userID, &task); err != nil {
+		writeParentError(w, err)
+		return
+	}
+
+	tags, err := resolveTags(db, userID, task.Tags)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
 	task.UserID = userID
-	result := db.Create(&task)
-	if result.Error != nil {
+	task.Tags = tags
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		return recordTaskEvent(tx, r, task.ID, userID, models.TaskCreated, initialFields(&task))
+	})
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
 			"Internal server error",
@@ -92,9 +174,51 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(task)
+	localizeTask(loc, &task)
+	writeTask(w, http.StatusCreated, loc, &task)
+}
+
+// GetTask returns a task with its ETag. If-None-Match with that ETag
+// returns 304 Not Modified.
+func GetTask(w http.ResponseWriter, r *http.Request) {
+	taskID := strings.TrimPrefix(r.URL.Path, "/v1/tasks/")
+	if taskID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			"Task ID is required",
+		))
+		return
+	}
+
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	var task models.Task
+	if err := db.Where("id = ? AND user_id = ?", taskID, middleware.GetUserID(r)).Preload("Tags").First(&task).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Task not found",
+		))
+		return
+	}
+
+	tasks := []models.Task{task}
+	if err := withProgress(db, tasks); err == nil {
+		task = tasks[0]
+	}
+	localizeTask(loc, &task)
+
+	if etag := taskETag(&task, loc); etagMatches(r.Header.Get("If-None-Match"), etag, true) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeTask(w, http.StatusOK, loc, &task)
 }
 
 func UpdateTask(w http.ResponseWriter, r *http.Request) {
@@ -120,9 +244,14 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
 	var task models.Task
 	userID := middleware.GetUserID(r)
-	if err := db.Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+	if err := db.Where("id = ? AND user_id = ?", taskID, userID).Preload("Tags").First(&task).Error; err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
 			"Not found",
@@ -131,9 +260,34 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkIfMatch(w, r, db, loc, &task) {
+		return
+	}
+	saveTaskUpdates(w, r, db, loc, userID, &task, &updates)
+}
+
+// saveTaskUpdates replaces the fields of task by those of updates, checks
+// the result and saves it along with the changes in the history. Tags are
+// only replaced when updates has them.
+func saveTaskUpdates(w http.ResponseWriter, r *http.Request, db database.Database, loc *time.Location, userID uint, task *models.Task, updates *models.Task) {
+	before := taskSnapshot(task)
+	dueChanged := !sameTime(task.Deadline, updates.Deadline) || !sameDate(task.DueDate, updates.DueDate)
 	task.Title = updates.Title
 	task.Description = updates.Description
 	task.Deadline = updates.Deadline
+	task.AllDay = updates.AllDay
+	task.DueDate = updates.DueDate
+	task.StartDate = updates.StartDate
+	task.Priority = updates.Priority
+	task.Important = updates.Important
+	task.Urgent = updates.Urgent
+	task.ProjectID = updates.ProjectID
+	task.ParentID = updates.ParentID
+	task.Recurrence = updates.Recurrence
+	task.RecurrenceTimezone = updates.RecurrenceTimezone
+	if task.Recurrence != "" && task.RecurrenceTimezone == "" {
+		task.RecurrenceTimezone = loc.String()
+	}
 
 	if err := task.Validate(); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -144,7 +298,53 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := db.Save(&task).Error; err != nil {
+	if err := normalizeRecurrence(task); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid request",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := checkProject(db, userID, task.ProjectID); err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	if err := checkParent(db, userID, task); err != nil {
+		writeParentError(w, err)
+		return
+	}
+
+	if updates.Tags != nil {
+		tags, err := resolveTags(db, userID, updates.Tags)
+		if err != nil {
+			writeTagError(w, err)
+			return
+		}
+		task.Tags = tags
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := saveTask(tx, task); err != nil {
+			return err
+		}
+		if dueChanged {
+			if err := reminders.Rearm(tx, task, loc); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(task).Association("Tags").Replace(task.Tags); err != nil {
+			return err
+		}
+		return recordTaskEvent(tx, r, task.ID, userID, models.TaskUpdated, diffTasks(before, taskSnapshot(task)))
+	})
+	if errors.Is(err, errVersionConflict) {
+		writeVersionConflict(w, db, loc, task.ID)
+		return
+	}
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
 			"Internal server error",
@@ -153,8 +353,12 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(task)
+	updated := []models.Task{*task}
+	if err := withProgress(db, updated); err == nil {
+		*task = updated[0]
+	}
+	localizeTask(loc, task)
+	writeTask(w, http.StatusOK, loc, task)
 }
 
 func DeleteTask(w http.ResponseWriter, r *http.Request) {
@@ -169,6 +373,11 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
 	var task models.Task
 	userID := middleware.GetUserID(r)
 	if err := db.Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
@@ -180,8 +389,44 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result := db.Delete(&task)
-	if result.Error != nil {
+	if !checkIfMatch(w, r, db, loc, &task) {
+		return
+	}
+
+	// Subtasks are deleted along with their parent. Deleting makes a new
+	// version, so ETags from before the delete don't match after a restore.
+	subtree, err := descendantIDs(db, task.ID)
+	if err == nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			deleted := map[string]interface{}{
+				"deleted_at": tx.NowFunc(),
+				"version":    gorm.Expr("version + 1"),
+			}
+			result := tx.Model(&models.Task{}).Where("id = ? AND version = ?", task.ID, task.Version).Updates(deleted)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errVersionConflict
+			}
+			if len(subtree) > 0 {
+				if err := tx.Model(&models.Task{}).Where("id IN ?", subtree).Updates(deleted).Error; err != nil {
+					return err
+				}
+			}
+			for _, id := range append([]string{task.ID}, subtree...) {
+				if err := recordTaskEvent(tx, r, id, userID, models.TaskDeleted, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if errors.Is(err, errVersionConflict) {
+		writeVersionConflict(w, db, loc, task.ID)
+		return
+	}
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
 			"Internal server error",
@@ -206,9 +451,14 @@ func ToggleTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
 	var task models.Task
 	userID := middleware.GetUserID(r)
-	if err := db.Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+	if err := db.Where("id = ? AND user_id = ?", taskID, userID).Preload("Tags").First(&task).Error; err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
 			"Not found",
@@ -217,8 +467,88 @@ func ToggleTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkIfMatch(w, r, db, loc, &task) {
+		return
+	}
+
+	// Completing a task follows completionMode for its open subtasks,
+	// reopening a subtask reopens the tasks it belongs to
+	var related []string
+	var err error
+	if task.Completed {
+		related, err = ancestorIDs(db, task.ParentID)
+	} else {
+		var subtree []string
+		subtree, err = descendantIDs(db, task.ID)
+		if err == nil && len(subtree) > 0 {
+			err = db.Where("id IN ? AND completed = ?", subtree, false).Model(&models.Task{}).Pluck("id", &related).Error
+		}
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to toggle task",
+		))
+		return
+	}
+
+	if !task.Completed && len(related) > 0 && completionMode != CompletionCascade {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Open subtasks",
+			fmt.Sprintf("Complete the %d open subtasks first", len(related)),
+		))
+		return
+	}
+
+	// Completing an occurrence of a recurring task creates the next one
+	before := taskSnapshot(&task)
 	task.Completed = !task.Completed
-	if err := db.Save(&task).Error; err != nil {
+	var next *models.Task
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := saveTask(tx, &task); err != nil {
+			return err
+		}
+		if len(related) > 0 {
+			// Ancestors that are already open stay as they are in the history
+			var changed []string
+			err := tx.Model(&models.Task{}).Where("id IN ? AND completed <> ?", related, task.Completed).Pluck("id", &changed).Error
+			if err != nil {
+				return err
+			}
+			for _, id := range changed {
+				completed := models.TaskChanges{"completed": {Before: !task.Completed, After: task.Completed}}
+				if err := recordTaskEvent(tx, r, id, userID, models.TaskToggled, completed); err != nil {
+					return err
+				}
+			}
+			err = tx.Model(&models.Task{}).Where("id IN ?", related).Updates(map[string]interface{}{
+				"completed": task.Completed,
+				"version":   gorm.Expr("version + 1"),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if task.Completed && task.Recurrence != "" {
+			var err error
+			if next, err = createNextOccurrence(tx, &task, loc); err != nil {
+				return err
+			}
+			if next != nil {
+				if err := recordTaskEvent(tx, r, next.ID, userID, models.TaskCreated, initialFields(next)); err != nil {
+					return err
+				}
+			}
+		}
+		return recordTaskEvent(tx, r, task.ID, userID, models.TaskToggled, diffTasks(before, taskSnapshot(&task)))
+	})
+	if errors.Is(err, errVersionConflict) {
+		writeVersionConflict(w, db, loc, task.ID)
+		return
+	}
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
 			"Internal server error",
@@ -227,24 +557,56 @@ func ToggleTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	response := map[string]interface{}{
 		"id":        task.ID,
 		"completed": task.Completed,
-	})
+		"version":   task.Version,
+	}
+	if next != nil {
+		response["next_task_id"] = next.ID
+	}
+
+	toggled := []models.Task{task}
+	if err := withProgress(db, toggled); err == nil {
+		task = toggled[0]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", taskETag(&task, loc))
+	json.NewEncoder(w).Encode(response)
 }
 
 func GetTodayTasks(w http.ResponseWriter, r *http.Request) {
 	db := database.CreateConnection()
-	var tasks []models.Task
 
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	opts, ok := parseListOptions(w, r, "deadline")
+	if !ok {
+		return
+	}
+
+	// Today holds tasks due today, timed or all-day, and tasks scheduled to
+	// start today. Days don't always have 24 hours when daylight saving time
+	// starts or ends.
 	userID := middleware.GetUserID(r)
-	today := time.Now().UTC()
-	startOfDay := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	today := startOfDay(time.Now(), loc)
+	date := models.DateOf(today)
+	query := db.Where("user_id = ? AND ((deadline >= ? AND deadline < ?) OR due_date = ? OR start_date = ?) AND "+started,
+		userID, today.UTC(), today.AddDate(0, 0, 1).UTC(), date, date, date)
+	if !includeArchived(r) {
+		query = withoutArchivedProjects(db, query)
+	}
 
-	result := db.Where("user_id = ? AND deadline BETWEEN ? AND ?", userID, startOfDay, endOfDay).Find(&tasks)
-	if result.Error != nil {
+	query, ok = taskFilter(w, r, db, query, loc)
+	if !ok {
+		return
+	}
+
+	tasks, next, err := listTasks(db, query, opts)
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
 			"Internal server error",
@@ -253,18 +615,41 @@ func GetTodayTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(TaskResponse{Tasks: tasks})
+	localize(loc, tasks)
+	writeTaskList(w, tasks, next, opts)
 }
 
 func GetBacklogTasks(w http.ResponseWriter, r *http.Request) {
 	db := database.CreateConnection()
-	var tasks []models.Task
 
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	opts, ok := parseListOptions(w, r, "deadline")
+	if !ok {
+		return
+	}
+
+	// The backlog holds open tasks that were due before today; those due
+	// today are in today's tasks and undated ones in the inbox
 	userID := middleware.GetUserID(r)
-	now := time.Now().UTC()
-	result := db.Where("user_id = ? AND deadline < ? AND completed = ?", userID, now, false).Find(&tasks)
-	if result.Error != nil {
+	today := startOfDay(time.Now(), loc)
+	date := models.DateOf(today)
+	query := db.Where("user_id = ? AND completed = ? AND (deadline < ? OR due_date < ?) AND "+started,
+		userID, false, today.UTC(), date, date)
+	if !includeArchived(r) {
+		query = withoutArchivedProjects(db, query)
+	}
+
+	query, ok = taskFilter(w, r, db, query, loc)
+	if !ok {
+		return
+	}
+
+	tasks, next, err := listTasks(db, query, opts)
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.NewErrorResponse(
 			"Internal server error",
@@ -273,6 +658,112 @@ func GetBacklogTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	localize(loc, tasks)
+	writeTaskList(w, tasks, next, opts)
+}
+
+// GetInboxTasks lists the open tasks without a deadline or due date
+func GetInboxTasks(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	opts, ok := parseListOptions(w, r, "created_at")
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserID(r)
+	today := models.DateOf(startOfDay(time.Now(), loc))
+	query := db.Where("user_id = ? AND completed = ? AND deadline IS NULL AND due_date IS NULL AND "+started, userID, false, today)
+	if !includeArchived(r) {
+		query = withoutArchivedProjects(db, query)
+	}
+
+	query, ok = taskFilter(w, r, db, query, loc)
+	if !ok {
+		return
+	}
+
+	tasks, next, err := listTasks(db, query, opts)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch inbox tasks",
+		))
+		return
+	}
+
+	localize(loc, tasks)
+	writeTaskList(w, tasks, next, opts)
+}
+
+// GetMatrixTasks groups the open tasks into the quadrants of the Eisenhower matrix
+func GetMatrixTasks(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	var tasks []models.Task
+
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserID(r)
+	query, ok := taskFilter(w, r, db, db.Where("user_id = ? AND completed = ?", userID, false), loc)
+	if !ok {
+		return
+	}
+
+	result := query.Order(dueColumn).Order("priority DESC").Preload("Tags").Find(&tasks)
+	if result.Error != nil || withProgress(db, tasks) != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch tasks",
+		))
+		return
+	}
+
+	localize(loc, tasks)
+	matrix := models.MatrixResponse{
+		Do:        []models.Task{},
+		Schedule:  []models.Task{},
+		Delegate:  []models.Task{},
+		Eliminate: []models.Task{},
+	}
+	now := time.Now().In(loc)
+	for _, task := range tasks {
+		switch task.Quadrant(now, urgencyWindow) {
+		case models.QuadrantDo:
+			matrix.Do = append(matrix.Do, task)
+		case models.QuadrantSchedule:
+			matrix.Schedule = append(matrix.Schedule, task)
+		case models.QuadrantDelegate:
+			matrix.Delegate = append(matrix.Delegate, task)
+		default:
+			matrix.Eliminate = append(matrix.Eliminate, task)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(TaskResponse{Tasks: tasks})
+	json.NewEncoder(w).Encode(matrix)
+}
+
+// sameTime compares optional times
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameDate(a, b *models.Date) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
@@ -7,6 +7,7 @@ import (
 	"just-do-it-api/models"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -64,7 +65,7 @@ func TestCreateTask(t *testing.T) {
 			task: models.Task{
 				Title:       "New Task",
 				Description: "New Description",
-				Deadline:    time.Now().Add(24 * time.Hour),
+				Deadline:    timePtr(time.Now().Add(24 * time.Hour)),
 			},
 			expectedCode: http.StatusCreated,
 			expectError:  false,
@@ -73,17 +74,92 @@ func TestCreateTask(t *testing.T) {
 			name: "Missing Title",
 			task: models.Task{
 				Description: "New Description",
-				Deadline:    time.Now().Add(24 * time.Hour),
+				Deadline:    timePtr(time.Now().Add(24 * time.Hour)),
 			},
 			expectedCode: http.StatusBadRequest,
 			expectError:  true,
 		},
 		{
-			name: "Missing Deadline",
+			name: "With Priority And Importance",
 			task: models.Task{
-				Title:       "New Task",
+				ID:        "important",
+				Title:     "Important Task",
+				Deadline:  timePtr(time.Now().Add(24 * time.Hour)),
+				Priority:  models.PriorityHigh,
+				Important: boolPtr(true),
+			},
+			expectedCode: http.StatusCreated,
+			expectError:  false,
+		},
+		{
+			name: "Invalid Priority",
+			task: models.Task{
+				Title:    "New Task",
+				Deadline: timePtr(time.Now().Add(24 * time.Hour)),
+				Priority: models.Priority(7),
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
+		{
+			name: "Without Deadline",
+			task: models.Task{
+				ID:          "undated",
+				Title:       "Someday",
 				Description: "New Description",
 			},
+			expectedCode: http.StatusCreated,
+			expectError:  false,
+		},
+		{
+			name: "All Day",
+			task: models.Task{
+				ID:        "all-day",
+				Title:     "Birthday",
+				AllDay:    true,
+				DueDate:   datePtr("2026-06-12"),
+				StartDate: datePtr("2026-06-05"),
+			},
+			expectedCode: http.StatusCreated,
+			expectError:  false,
+		},
+		{
+			name: "All Day Without Due Date",
+			task: models.Task{
+				Title:  "Birthday",
+				AllDay: true,
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
+		{
+			name: "All Day With Deadline",
+			task: models.Task{
+				Title:    "Birthday",
+				AllDay:   true,
+				DueDate:  datePtr("2026-06-12"),
+				Deadline: timePtr(time.Now().Add(24 * time.Hour)),
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
+		{
+			name: "Due Date Without All Day",
+			task: models.Task{
+				Title:   "Birthday",
+				DueDate: datePtr("2026-06-12"),
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
+		{
+			name: "Start After Due Date",
+			task: models.Task{
+				Title:     "Birthday",
+				AllDay:    true,
+				DueDate:   datePtr("2026-06-12"),
+				StartDate: datePtr("2026-06-13"),
+			},
 			expectedCode: http.StatusBadRequest,
 			expectError:  true,
 		},
@@ -128,6 +204,10 @@ func TestCreateTask(t *testing.T) {
 					t.Errorf("handler returned unexpected title: got %v want %v",
 						response.Title, tt.task.Title)
 				}
+				if response.Priority != tt.task.Priority {
+					t.Errorf("handler returned unexpected priority: got %v want %v",
+						response.Priority, tt.task.Priority)
+				}
 			}
 		})
 	}
@@ -148,7 +228,7 @@ func TestUpdateTask(t *testing.T) {
 			updates: models.Task{
 				Title:       "Updated Task",
 				Description: "Updated Description",
-				Deadline:    time.Now().Add(24 * time.Hour),
+				Deadline:    timePtr(time.Now().Add(24 * time.Hour)),
 			},
 			expectedCode: http.StatusOK,
 		},
@@ -158,7 +238,7 @@ func TestUpdateTask(t *testing.T) {
 			updates: models.Task{
 				Title:       "Updated Task",
 				Description: "Updated Description",
-				Deadline:    time.Now().Add(24 * time.Hour),
+				Deadline:    timePtr(time.Now().Add(24 * time.Hour)),
 			},
 			expectedCode: http.StatusNotFound,
 		},
@@ -353,3 +433,152 @@ func TestGetBacklogTasks(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestAllDayAndStartDates(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	user := models.User{Email: "planner@example.com", Password: "hash"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	day := func(offset int) *models.Date {
+		date := models.DateOf(now.AddDate(0, 0, offset))
+		return &date
+	}
+	tasks := []models.Task{
+		{ID: "due-today", Title: "Due today", AllDay: true, DueDate: day(0)},
+		{ID: "due-yesterday", Title: "Due yesterday", AllDay: true, DueDate: day(-1)},
+		{ID: "done-yesterday", Title: "Done yesterday", AllDay: true, DueDate: day(-1), Completed: true},
+		{ID: "starts-today", Title: "Starts today", StartDate: day(0)},
+		{ID: "starts-tomorrow", Title: "Starts tomorrow", StartDate: day(1)},
+		{ID: "someday", Title: "Someday"},
+		{ID: "timed", Title: "Timed", Deadline: timePtr(now.Add(-48 * time.Hour))},
+	}
+	for i, task := range tasks {
+		task.UserID = user.ID
+		task.CreatedAt = now.Add(time.Duration(i) * time.Second)
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		path     string
+		expected string
+	}{
+		{"Today", GetTodayTasks, "/v1/tasks/today", "due-today,starts-today"},
+		{"Backlog", GetBacklogTasks, "/v1/tasks/backlog", "timed,due-yesterday"},
+		{"Inbox", GetInboxTasks, "/v1/tasks/inbox", "starts-today,someday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := zonedRequest(t, tt.handler, tt.path, user.ID, "UTC")
+			if ids := responseTaskIDs(t, rr); ids != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, ids)
+			}
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func datePtr(s string) *models.Date {
+	date, err := models.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return &date
+}
+
+func TestPriorityJSON(t *testing.T) {
+	var task models.Task
+	if err := json.Unmarshal([]byte(`{"title": "Task", "priority": "urgent"}`), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.Priority != models.PriorityUrgent {
+		t.Errorf("expected priority urgent, got %v", task.Priority)
+	}
+
+	encoded, _ := json.Marshal(task)
+	if !bytes.Contains(encoded, []byte(`"priority":"urgent"`)) {
+		t.Errorf("expected the priority to be serialized by name, got %s", encoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"priority": "whenever"}`), &task); err == nil {
+		t.Error("expected an unknown priority to be rejected")
+	}
+}
+
+func TestGetMatrixTasks(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	// Drop the fixtures so only the tasks below are open
+	db.Where("1 = 1").Delete(&models.Task{})
+
+	soon := time.Now().Add(time.Hour)
+	later := time.Now().Add(30 * 24 * time.Hour)
+	tasks := []models.Task{
+		{ID: "do-explicit", Title: "Explicit", Deadline: timePtr(later), Important: boolPtr(true), Urgent: boolPtr(true)},
+		{ID: "do-derived", Title: "Derived", Deadline: timePtr(soon), Priority: models.PriorityUrgent},
+		{ID: "schedule", Title: "Schedule", Deadline: timePtr(later), Priority: models.PriorityHigh},
+		{ID: "delegate", Title: "Delegate", Deadline: timePtr(soon), Priority: models.PriorityLow},
+		{ID: "eliminate", Title: "Eliminate", Deadline: timePtr(soon), Important: boolPtr(false), Urgent: boolPtr(false)},
+		{ID: "done", Title: "Done", Deadline: timePtr(soon), Priority: models.PriorityUrgent, Completed: true},
+	}
+	for _, task := range tasks {
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/tasks/matrix", nil)
+	rr := httptest.NewRecorder()
+	GetMatrixTasks(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var matrix models.MatrixResponse
+	if err := json.NewDecoder(rr.Body).Decode(&matrix); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(tasks []models.Task) []string {
+		var ids []string
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	expected := map[string][]string{
+		models.QuadrantDo:        {"do-derived", "do-explicit"},
+		models.QuadrantSchedule:  {"schedule"},
+		models.QuadrantDelegate:  {"delegate"},
+		models.QuadrantEliminate: {"eliminate"},
+	}
+	actual := map[string][]string{
+		models.QuadrantDo:        ids(matrix.Do),
+		models.QuadrantSchedule:  ids(matrix.Schedule),
+		models.QuadrantDelegate:  ids(matrix.Delegate),
+		models.QuadrantEliminate: ids(matrix.Eliminate),
+	}
+	for quadrant, want := range expected {
+		if strings.Join(actual[quadrant], ",") != strings.Join(want, ",") {
+			t.Errorf("quadrant %s: got %v want %v", quadrant, actual[quadrant], want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/database"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TimezoneHeader overrides the time zone of the user for a single request,
+// like the tz query parameter
+const TimezoneHeader = "X-Timezone"
+
+// requestLocation returns the time zone that day boundaries and deadlines
+// of the request use: the tz query parameter, the X-Timezone header or the
+// time zone of the user's profile, in that order. The zone is echoed in the
+// X-Timezone response header.
+func requestLocation(w http.ResponseWriter, r *http.Request, db database.Database) (*time.Location, bool) {
+	name := strings.TrimSpace(r.URL.Query().Get("tz"))
+	if name == "" {
+		name = strings.TrimSpace(r.Header.Get(TimezoneHeader))
+	}
+
+	if name == "" {
+		var user models.User
+		if err := db.Where("id = ?", middleware.GetUserID(r)).Select("timezone").First(&user).Error; err == nil {
+			name = user.Timezone
+		}
+	} else if !validTimezone(name) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Invalid time zone",
+			"Time zone must be an IANA time zone such as America/Sao_Paulo",
+		))
+		return nil, false
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" {
+		loc = time.UTC
+	}
+	w.Header().Set(TimezoneHeader, loc.String())
+	return loc, true
+}
+
+// validTimezone accepts IANA names; "Local" would be the zone of the server
+func validTimezone(name string) bool {
+	if name == "" || strings.EqualFold(name, "local") {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// startOfDay returns midnight of the day t falls on in loc
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// localize renders the deadlines of tasks in loc
+func localize(loc *time.Location, tasks []models.Task) {
+	for i := range tasks {
+		localizeTask(loc, &tasks[i])
+	}
+}
+
+func localizeTask(loc *time.Location, task *models.Task) {
+	if task.Deadline != nil {
+		deadline := task.Deadline.In(loc)
+		task.Deadline = &deadline
+	}
+}
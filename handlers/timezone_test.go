@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDayBoundariesUseUserTimezone(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	user := models.User{Email: "office@example.com", Password: "hash", Timezone: "America/Sao_Paulo"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	saoPaulo, _ := time.LoadLocation("America/Sao_Paulo")
+	today := startOfDay(time.Now(), saoPaulo)
+	tasks := []models.Task{
+		// 10pm in the office is already tomorrow in UTC
+		{ID: "tonight", UserID: user.ID, Title: "Tonight", Deadline: timePtr(today.Add(22 * time.Hour).UTC())},
+		{ID: "last-night", UserID: user.ID, Title: "Last night", Deadline: timePtr(today.Add(-2 * time.Hour).UTC())},
+		{ID: "march-tenth", UserID: user.ID, Title: "March 10", Deadline: timePtr(time.Date(2026, 3, 10, 22, 0, 0, 0, saoPaulo).UTC())},
+	}
+	for _, task := range tasks {
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		path     string
+		header   string
+		expected string
+	}{
+		{"Today", GetTodayTasks, "/v1/tasks/today", "", "tonight"},
+		{"Backlog", GetBacklogTasks, "/v1/tasks/backlog", "", "march-tenth,last-night"},
+		{"Deadline", GetTasks, "/v1/tasks?deadline=2026-03-10", "", "march-tenth"},
+		{"Deadline In UTC By Query", GetTasks, "/v1/tasks?deadline=2026-03-11&tz=UTC", "", "march-tenth"},
+		{"Deadline In UTC By Header", GetTasks, "/v1/tasks?deadline=2026-03-10", "UTC", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := responseTaskIDs(t, zonedRequest(t, tt.handler, tt.path, user.ID, tt.header))
+			if ids != tt.expected {
+				t.Errorf("got %q want %q", ids, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDeadlinesAreRenderedInUserTimezone(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	user := models.User{Email: "tokyo@example.com", Password: "hash", Timezone: "Asia/Tokyo"}
+	db.Create(&user)
+	db.Create(&models.Task{ID: "rendered", UserID: user.ID, Title: "Rendered", Deadline: timePtr(time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC))})
+
+	rr := zonedRequest(t, GetTasks, "/v1/tasks", user.ID, "")
+	if !strings.Contains(rr.Body.String(), `"deadline":"2026-05-01T21:00:00+09:00"`) {
+		t.Errorf("expected the deadline in Tokyo time, got %s", rr.Body.String())
+	}
+	if zone := rr.Header().Get(TimezoneHeader); zone != "Asia/Tokyo" {
+		t.Errorf("expected the %s header to be Asia/Tokyo, got %q", TimezoneHeader, zone)
+	}
+
+	rr = zonedRequest(t, GetTasks, "/v1/tasks", user.ID, "Mars/Olympus_Mons")
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown time zone, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"just-do-it-api/database"
+	"just-do-it-api/jobs"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// trashedTask loads a deleted task of the user
+func trashedTask(w http.ResponseWriter, r *http.Request, db database.Database, taskID string) (*models.Task, bool) {
+	var task models.Task
+	err := db.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", taskID, middleware.GetUserID(r)).
+		Preload("Tags").
+		First(&task).Error
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Not found",
+			"Task not found in the trash",
+		))
+		return nil, false
+	}
+	return &task, true
+}
+
+// trashedSubtree returns the IDs of the subtasks of a task that were deleted
+// at deletedSince or later, i.e. along with the task or after it
+func trashedSubtree(db database.Database, taskID string, deletedSince time.Time) ([]string, error) {
+	var ids []string
+	current := []string{taskID}
+	for depth := 0; len(current) > 0 && depth <= maxTaskDepth; depth++ {
+		var children []string
+		err := db.Unscoped().Model(&models.Task{}).
+			Where("parent_id IN ? AND deleted_at IS NOT NULL AND deleted_at >= ?", current, deletedSince).
+			Pluck("id", &children).Error
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, children...)
+		current = children
+	}
+	return ids, nil
+}
+
+// GetTrash lists the deleted tasks of the user, most recently deleted first
+func GetTrash(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+
+	var tasks []models.Task
+	err := db.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", middleware.GetUserID(r)).
+		Order("deleted_at DESC").
+		Order("id").
+		Preload("Tags").
+		Find(&tasks).Error
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to fetch the trash",
+		))
+		return
+	}
+
+	localize(loc, tasks)
+	trashed := make([]models.TrashedTask, len(tasks))
+	for i, task := range tasks {
+		deletedAt := task.DeletedAt.Time.In(loc)
+		trashed[i] = models.TrashedTask{
+			Task:      task,
+			DeletedAt: deletedAt,
+			PurgeAt:   jobs.TrashPurgeAt(deletedAt),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.TrashResponse{Tasks: trashed})
+}
+
+// RestoreTask takes a task out of the trash along with the subtasks that
+// were deleted with it
+func RestoreTask(w http.ResponseWriter, r *http.Request) {
+	taskID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/tasks/"), "/restore")
+
+	db := database.CreateConnection()
+	loc, ok := requestLocation(w, r, db)
+	if !ok {
+		return
+	}
+	task, ok := trashedTask(w, r, db, taskID)
+	if !ok {
+		return
+	}
+
+	// A subtask can't come back without the task it belongs to
+	if task.ParentID != nil {
+		if err := db.Where("id = ?", *task.ParentID).Select("id").First(&models.Task{}).Error; err != nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Parent deleted",
+				"Restore the task this subtask belongs to first",
+			))
+			return
+		}
+	}
+
+	subtree, err := trashedSubtree(db, task.ID, task.DeletedAt.Time)
+	if err == nil {
+		restored := append([]string{task.ID}, subtree...)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Unscoped().Model(&models.Task{}).
+				Where("id IN ?", restored).
+				Updates(map[string]interface{}{
+					"deleted_at": nil,
+					"version":    gorm.Expr("version + 1"),
+				}).Error
+			if err != nil {
+				return err
+			}
+			for _, id := range restored {
+				if err := recordTaskEvent(tx, r, id, task.UserID, models.TaskRestored, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to restore task",
+		))
+		return
+	}
+
+	task.DeletedAt = gorm.DeletedAt{}
+	task.Version++
+	restored := []models.Task{*task}
+	if err := withProgress(db, restored); err == nil {
+		task = &restored[0]
+	}
+	localizeTask(loc, task)
+	writeTask(w, http.StatusOK, loc, task)
+}
+
+// PurgeTrashedTask deletes a task in the trash for good, along with its subtasks
+func PurgeTrashedTask(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+	task, ok := trashedTask(w, r, db, strings.TrimPrefix(r.URL.Path, "/v1/trash/"))
+	if !ok {
+		return
+	}
+
+	subtree, err := trashedSubtree(db, task.ID, time.Time{})
+	if err == nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return tx.Unscoped().Where("id IN ?", append(subtree, task.ID)).Delete(&models.Task{}).Error
+		})
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to purge task",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EmptyTrash deletes every task in the trash of the user for good
+func EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	db := database.CreateConnection()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", middleware.GetUserID(r)).Delete(&models.Task{}).Error
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Internal server error",
+			"Failed to empty the trash",
+		))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
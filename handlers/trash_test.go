@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"just-do-it-api/database"
+	"just-do-it-api/jobs"
+	"just-do-it-api/models"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestoreTask(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	tasks := []map[string]interface{}{
+		{"id": "trip", "title": "Trip"},
+		{"id": "passport", "title": "Renew passport", "parent_id": "trip"},
+		{"id": "tickets", "title": "Book tickets", "parent_id": "trip"},
+	}
+	for _, task := range tasks {
+		if rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", task); rr.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+	}
+
+	// The tickets were deleted on their own an hour before the trip
+	deleteTask(t, "tickets")
+	if err := db.Unscoped().Model(&models.Task{}).Where("id = ?", "tickets").Update("deleted_at", time.Now().Add(-time.Hour).UTC()).Error; err != nil {
+		t.Fatal(err)
+	}
+	deleteTask(t, "trip")
+	if got := trashIDs(t); got != "passport,trip,tickets" {
+		t.Errorf("expected the trip, its subtask and the tickets in the trash, got %q", got)
+	}
+
+	var trash models.TrashResponse
+	if err := json.NewDecoder(handlerRequest(t, GetTrash, http.MethodGet, "/v1/trash", nil).Body).Decode(&trash); err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range trash.Tasks {
+		if task.DeletedAt.IsZero() || !task.PurgeAt.After(task.DeletedAt) {
+			t.Errorf("expected %s to have deletion and purge times, got %v and %v", task.ID, task.DeletedAt, task.PurgeAt)
+		}
+	}
+
+	rr := handlerRequest(t, RestoreTask, http.MethodPost, "/v1/tasks/passport/restore", nil)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected status %d restoring a subtask of a deleted task, got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+	}
+
+	rr = handlerRequest(t, RestoreTask, http.MethodPost, "/v1/tasks/trip/restore", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var restored models.Task
+	if err := json.NewDecoder(rr.Body).Decode(&restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.ID != "trip" || restored.Version != 3 || etagVersion(rr.Header().Get("ETag")) != "3" {
+		t.Errorf("expected the restored trip in its next version, got %+v", restored)
+	}
+	if got := trashIDs(t); got != "tickets" {
+		t.Errorf("expected the subtask deleted with the trip to be restored too, got %q left", got)
+	}
+	if got := taskIDs(t, GetTasks, "/v1/tasks?q=title:passport"); got != "passport" {
+		t.Errorf("expected the passport task to be back, got %q", got)
+	}
+
+	if rr := handlerRequest(t, RestoreTask, http.MethodPost, "/v1/tasks/tickets/restore", nil); rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if rr := handlerRequest(t, RestoreTask, http.MethodPost, "/v1/tasks/trip/restore", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a task that isn't in the trash, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	for _, id := range []string{"junk", "clutter"} {
+		if rr := handlerRequest(t, CreateTask, http.MethodPost, "/v1/tasks", map[string]interface{}{"id": id, "title": id}); rr.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+	}
+	foreign := models.Task{ID: "foreign", UserID: 42, Title: "Someone else's"}
+	if err := db.Create(&foreign).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&foreign).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	deleteTask(t, "junk")
+	deleteTask(t, "clutter")
+	deleteTask(t, "1")
+	if got := trashIDs(t); strings.Contains(got, "foreign") {
+		t.Errorf("expected only the user's tasks in the trash, got %q", got)
+	}
+
+	if rr := handlerRequest(t, PurgeTrashedTask, http.MethodDelete, "/v1/trash/junk", nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+	var count int64
+	db.Unscoped().Model(&models.Task{}).Where("id = ?", "junk").Count(&count)
+	if count != 0 {
+		t.Error("expected the purged task to be gone")
+	}
+	if rr := handlerRequest(t, PurgeTrashedTask, http.MethodDelete, "/v1/trash/2", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a task that isn't in the trash, got %d", http.StatusNotFound, rr.Code)
+	}
+	if rr := handlerRequest(t, PurgeTrashedTask, http.MethodDelete, "/v1/trash/foreign", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for another user's task, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	if rr := handlerRequest(t, EmptyTrash, http.MethodDelete, "/v1/trash", nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+	if got := trashIDs(t); got != "" {
+		t.Errorf("expected an empty trash, got %q", got)
+	}
+	db.Unscoped().Model(&models.Task{}).Where("id = ?", "foreign").Count(&count)
+	if count != 1 {
+		t.Error("expected emptying the trash to leave other users' tasks alone")
+	}
+}
+
+func TestTrashRetention(t *testing.T) {
+	setupTest(t)
+	db := database.CreateConnection()
+
+	deleteTask(t, "1")
+	deleteTask(t, "2")
+	expired := time.Now().AddDate(0, 0, -jobs.TrashRetentionDays-1).UTC()
+	if err := db.Unscoped().Model(&models.Task{}).Where("id = ?", "1").Update("deleted_at", expired).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := jobs.PurgeTrash(context.Background(), db, time.Now())
+	if err != nil || purged != 1 {
+		t.Fatalf("expected one task to be purged, got %d: %v", purged, err)
+	}
+	if got := trashIDs(t); got != "2" {
+		t.Errorf("expected the recently deleted task to stay in the trash, got %q", got)
+	}
+}
@@ -0,0 +1,77 @@
+package jobs
+
+import (
+	"context"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// dueForPurge matches accounts whose deletion grace period is over, and
+// accounts that were soft deleted before deletions were scheduled
+const dueForPurge = "(deleted_at IS NOT NULL OR (deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?))"
+
+// PurgeDeletedAccounts hard deletes every account that is due and returns how many were removed
+func PurgeDeletedAccounts(ctx context.Context, db database.Database, now time.Time) (int, error) {
+	var users []models.User
+	if err := db.Unscoped().Where(dueForPurge, now).Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return purged, ctx.Err()
+		}
+
+		ok, err := PurgeAccount(db, user.ID, now)
+		if err != nil {
+			return purged, err
+		}
+		if ok {
+			purged++
+		}
+	}
+
+	if purged > 0 {
+		log.Printf("Purged %d deleted accounts", purged)
+	}
+	return purged, nil
+}
+
+// PurgeAccount hard deletes the user, their tasks and the history of the
+// tasks if the account is still due at now; a deletion canceled in the
+// meantime is left alone. Tasks are soft deleted normally, so the foreign key
+// cascade alone would not remove them. Task events are append-only otherwise,
+// so their trigger has to be told this is a purge. Sessions, tokens and other
+// rows go with the user through ON DELETE CASCADE.
+func PurgeAccount(db database.Database, userID uint, now time.Time) (bool, error) {
+	purged := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SET LOCAL task_events.purge = 'on'").Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().Where("id = ? AND "+dueForPurge, userID, now).Delete(&models.User{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.Task{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TaskEvent{}).Error; err != nil {
+			return err
+		}
+
+		purged = true
+		return nil
+	})
+	return purged, err
+}
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"just-do-it-api/config"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"log"
+	"time"
+)
+
+// TrashRetentionDays is how many days deleted tasks stay in the trash before
+// they are purged
+var TrashRetentionDays = config.Int("TRASH_RETENTION_DAYS", 30)
+
+// trashPurgeBatch bounds the tasks deleted by one statement
+const trashPurgeBatch = 500
+
+// TrashPurgeAt returns when a task deleted at deletedAt leaves the trash for good
+func TrashPurgeAt(deletedAt time.Time) time.Time {
+	return deletedAt.AddDate(0, 0, TrashRetentionDays)
+}
+
+// PurgeTrash hard deletes the tasks that have been in the trash longer than
+// the retention period and returns how many were removed
+func PurgeTrash(ctx context.Context, db database.Database, now time.Time) (int, error) {
+	cutoff := now.AddDate(0, 0, -TrashRetentionDays).UTC()
+
+	purged := 0
+	for {
+		if ctx.Err() != nil {
+			return purged, ctx.Err()
+		}
+
+		var ids []string
+		err := db.Unscoped().Model(&models.Task{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Limit(trashPurgeBatch).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return purged, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		result := db.Unscoped().Where("id IN ?", ids).Delete(&models.Task{})
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += int(result.RowsAffected)
+		if len(ids) < trashPurgeBatch {
+			break
+		}
+	}
+
+	if purged > 0 {
+		log.Printf("Purged %d tasks from the trash", purged)
+	}
+	return purged, nil
+}
@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"just-do-it-api/config"
+	"log"
+	"mime"
+	"time"
+)
+
+// From is the sender address used for every outgoing message
+var From = config.String("MAIL_FROM", "Just Do It <no-reply@just-do-it.local>")
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages to users
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer selected by the MAILER environment variable: "outbox" (default) or "smtp"
+func New() Mailer {
+	switch backend := config.String("MAILER", "outbox"); backend {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     config.String("SMTP_HOST", "localhost"),
+			Port:     config.Int("SMTP_PORT", 1025),
+			Username: config.String("SMTP_USERNAME", ""),
+			Password: config.String("SMTP_PASSWORD", ""),
+		}
+	case "outbox":
+		return &OutboxMailer{Dir: config.String("MAIL_OUTBOX_DIR", "outbox")}
+	default:
+		log.Printf("Unknown mailer %q, falling back to outbox", backend)
+		return &OutboxMailer{Dir: config.String("MAIL_OUTBOX_DIR", "outbox")}
+	}
+}
+
+// format renders the message as an RFC 5322 plain text email
+func format(from string, msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(msg.Body)
+	return buf.Bytes()
+}
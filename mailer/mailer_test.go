@@ -0,0 +1,110 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeSMTPServer accepts a single message and sends its DATA section on the returned channel
+func fakeSMTPServer(t *testing.T) (net.Listener, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		write("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				write("250 localhost")
+			case strings.HasPrefix(command, "DATA"):
+				write("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				received <- data.String()
+				write("250 OK")
+			case strings.HasPrefix(command, "QUIT"):
+				write("221 Bye")
+				return
+			default:
+				write("250 OK")
+			}
+		}
+	}()
+
+	return listener, received
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	listener, received := fakeSMTPServer(t)
+	defer listener.Close()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	m := &SMTPMailer{Host: "127.0.0.1", Port: addr.Port}
+
+	err := m.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Hello",
+		Body:    "Welcome aboard",
+	})
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	data := <-received
+	if !strings.Contains(data, "To: user@example.com") {
+		t.Errorf("expected recipient header, got %q", data)
+	}
+	if !strings.Contains(data, "Welcome aboard") {
+		t.Errorf("expected message body, got %q", data)
+	}
+}
+
+func TestOutboxMailer(t *testing.T) {
+	m := &OutboxMailer{Dir: t.TempDir()}
+
+	for _, subject := range []string{"First", "Second"} {
+		if err := m.Send(context.Background(), Message{To: "user@example.com", Subject: subject, Body: "Body"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages, err := m.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Subject != "First" || messages[1].Subject != "Second" {
+		t.Errorf("unexpected message order: %q, %q", messages[0].Subject, messages[1].Subject)
+	}
+}
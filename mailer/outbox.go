@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// OutboxMailer writes every message as an .eml file into Dir instead of
+// sending it. It is meant for development and tests.
+type OutboxMailer struct {
+	Dir string
+}
+
+func (o *OutboxMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(o.Dir, 0o755); err != nil {
+		return fmt.Errorf("could not create outbox directory: %v", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	return os.WriteFile(filepath.Join(o.Dir, name), format(From, msg), 0o644)
+}
+
+// Messages returns the messages in the outbox, oldest first
+func (o *OutboxMailer) Messages() ([]Message, error) {
+	entries, err := os.ReadDir(o.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".eml" {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	messages := make([]Message, 0, len(names))
+	for _, name := range names {
+		f, err := os.Open(filepath.Join(o.Dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, err := mail.ReadMessage(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not parse %s: %v", name, err)
+		}
+		body, err := io.ReadAll(parsed.Body)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+		if err != nil {
+			subject = parsed.Header.Get("Subject")
+		}
+
+		messages = append(messages, Message{
+			To:      parsed.Header.Get("To"),
+			Subject: subject,
+			Body:    string(body),
+		})
+	}
+
+	return messages, nil
+}
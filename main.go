@@ -1,12 +1,25 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"just-do-it-api/auth"
+	"just-do-it-api/config"
 	"just-do-it-api/database"
+	"just-do-it-api/export"
+	"just-do-it-api/jobs"
+	"just-do-it-api/mailer"
 	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"just-do-it-api/reminders"
 	"just-do-it-api/routes"
 )
 
@@ -26,6 +39,11 @@ func main() {
 		}
 	}
 
+	// Load JWT signing keys
+	if err := auth.LoadKeys(); err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+
 	// Initialize database connection
 	database.CreateConnection()
 
@@ -34,11 +52,67 @@ func main() {
 
 	// Register routes on the mux
 	routes.RegisterTaskRoutes(mux)
+	routes.RegisterTagRoutes(mux)
+	routes.RegisterProjectRoutes(mux)
+	routes.RegisterQueryRoutes(mux)
+	routes.RegisterTrashRoutes(mux)
+	routes.RegisterReminderRoutes(mux)
 	routes.RegisterAuthRoutes(mux)
+	routes.RegisterAccountRoutes(mux)
+	routes.RegisterAdminRoutes(mux)
+
+	// Stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background jobs
+	var wg sync.WaitGroup
+	every := func(name string, interval time.Duration, fn func(ctx context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jobs.Every(ctx, name, interval, fn)
+		}()
+	}
+	every("account purge", config.Duration("ACCOUNT_PURGE_INTERVAL", time.Hour), func(ctx context.Context) error {
+		_, err := jobs.PurgeDeletedAccounts(ctx, database.CreateConnection(), time.Now())
+		return err
+	})
+	every("trash purge", config.Duration("TRASH_PURGE_INTERVAL", time.Hour), func(ctx context.Context) error {
+		_, err := jobs.PurgeTrash(ctx, database.CreateConnection(), time.Now())
+		return err
+	})
+	every("export cleanup", config.Duration("EXPORT_CLEANUP_INTERVAL", time.Hour), func(ctx context.Context) error {
+		return export.PurgeExpired(ctx, database.CreateConnection(), time.Now())
+	})
+
+	scheduler := reminders.NewScheduler(database.CreateConnection(), map[string]reminders.Channel{
+		models.ChannelEmail:   &reminders.EmailChannel{Mailer: mailer.New()},
+		models.ChannelWebhook: reminders.NewWebhookChannel(),
+		models.ChannelInApp:   &reminders.InAppChannel{DB: database.CreateConnection()},
+	})
+	every("reminders", config.Duration("REMINDER_POLL_INTERVAL", 30*time.Second), scheduler.Poll)
 
 	// Apply CORS middleware
 	handler := middleware.CorsMiddleware()(mux)
+	server := &http.Server{Addr: ":8080", Handler: handler}
+
+	go func() {
+		log.Printf("Server starting on :8080")
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
 
-	log.Printf("Server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", handler))
+	<-ctx.Done()
+	log.Printf("Shutting down")
+
+	// Let running requests and jobs finish; reminders that are being
+	// delivered when the timeout hits are retried once their lock expires
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Duration("SHUTDOWN_TIMEOUT", 30*time.Second))
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down the server: %v", err)
+	}
+	wg.Wait()
 }
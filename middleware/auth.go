@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"just-do-it-api/auth"
+	"just-do-it-api/database"
 	"just-do-it-api/models"
 	"net/http"
 	"strings"
@@ -11,7 +12,12 @@ import (
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey    contextKey = "userID"
+	SessionIDKey contextKey = "sessionID"
+	ScopesKey    contextKey = "scopes"
+	UserKey      contextKey = "user"
+)
 
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -47,6 +53,30 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// Personal access tokens carry their own scopes and have no session
+		if auth.IsPersonalAccessToken(token) {
+			pat, err := auth.ValidatePersonalAccessToken(database.CreateConnection(), token)
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(models.NewErrorResponse(
+					"Unauthorized",
+					"Invalid token",
+				))
+				return
+			}
+
+			user, ok := activeUser(w, pat.UserID)
+			if !ok {
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserIDKey, pat.UserID)
+			ctx = context.WithValue(ctx, UserKey, user)
+			ctx = context.WithValue(ctx, ScopesKey, pat.Scopes)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		claims, err := auth.ValidateToken(token)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
@@ -57,14 +87,67 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// Add user ID to request context
+		if err := auth.ValidateSession(database.CreateConnection(), claims.SessionID); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Unauthorized",
+				"Session has been revoked",
+			))
+			return
+		}
+
+		// Tokens stay valid until they expire, so the account is checked on every request
+		user, ok := activeUser(w, claims.UserID)
+		if !ok {
+			return
+		}
+
+		// Add user and session IDs to request context
 		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, UserKey, user)
+		ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
 
+// activeUser loads the user a token belongs to and rejects disabled accounts
+func activeUser(w http.ResponseWriter, userID uint) (*models.User, bool) {
+	var user models.User
+	if err := database.CreateConnection().First(&user, userID).Error; err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Unauthorized",
+			"User not found",
+		))
+		return nil, false
+	}
+
+	if user.Disabled() {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Account disabled",
+			"This account has been disabled",
+		))
+		return nil, false
+	}
+
+	return &user, true
+}
+
 // GetUserID retrieves the user ID from the request context
 func GetUserID(r *http.Request) uint {
 	userID, _ := r.Context().Value(UserIDKey).(uint)
 	return userID
 }
+
+// GetSessionID retrieves the session ID of the access token from the request context
+func GetSessionID(r *http.Request) string {
+	sessionID, _ := r.Context().Value(SessionIDKey).(string)
+	return sessionID
+}
+
+// GetUser retrieves the authenticated user loaded by AuthMiddleware
+func GetUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(UserKey).(*models.User)
+	return user
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"just-do-it-api/config"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustProxyHeaders makes ClientIP honor X-Forwarded-For. Only enable it behind a proxy that sets the header.
+var TrustProxyHeaders = config.Bool("TRUST_PROXY_HEADERS", false)
+
+// ClientIP returns the address of the client that sent the request
+func ClientIP(r *http.Request) string {
+	if TrustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -28,6 +28,7 @@ func (rw *responseWriter) WriteHeader(statusCode int) {
 func Logger(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
+		r = withRequestID(w, r)
 
 		// Log request
 		var requestBody []byte
@@ -72,6 +73,7 @@ func Logger(next http.HandlerFunc) http.HandlerFunc {
 		// Log the request and response details
 		log.Printf(`
 Request:
+  ID: %s
   Method: %s
   Path: %s
   Headers: %v
@@ -82,6 +84,7 @@ Response:
   Duration: %v
   Body: %s
 `,
+			GetRequestID(r),
 			r.Method,
 			r.URL.Path,
 			r.Header,
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"encoding/json"
+	"just-do-it-api/models"
+	"net/http"
+)
+
+// RequireRole rejects users that don't have the role. The role is read from
+// the database by AuthMiddleware, so changes apply before tokens expire.
+func RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if user := GetUser(r); user == nil || user.Role != role {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Forbidden",
+				"You don't have permission to use this endpoint",
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
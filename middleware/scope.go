@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"encoding/json"
+	"just-do-it-api/models"
+	"net/http"
+)
+
+// GetScopes returns the scopes of the personal access token used for the
+// request. ok is false for requests made with a user session, which are not
+// limited by scopes.
+func GetScopes(r *http.Request) (scopes models.ScopeList, ok bool) {
+	scopes, ok = r.Context().Value(ScopesKey).(models.ScopeList)
+	return scopes, ok
+}
+
+// RequireScope rejects requests made with a personal access token that was not granted scope
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if scopes, ok := GetScopes(r); ok && !scopes.Contains(scope) {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Forbidden",
+				"Token is missing the "+scope+" scope",
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// RequireSession rejects requests made with a personal access token. Account
+// management such as minting tokens or ending sessions needs a real login.
+func RequireSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if GetSessionID(r) == "" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Forbidden",
+				"This endpoint requires a user session",
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"encoding/json"
+	"just-do-it-api/config"
+	"just-do-it-api/models"
+	"net/http"
+)
+
+// Modes for users that have not verified their email address yet
+const (
+	UnverifiedFull     = "full"      // no restrictions
+	UnverifiedReadOnly = "read_only" // only safe methods (GET, HEAD, OPTIONS)
+	UnverifiedBlocked  = "blocked"   // no access until the email is verified
+)
+
+// UnverifiedUserMode controls what users with an unverified email may do
+var UnverifiedUserMode = config.String("UNVERIFIED_USER_MODE", UnverifiedReadOnly)
+
+// RequireVerifiedEmail limits users with an unverified email according to
+// UnverifiedUserMode. It must run after AuthMiddleware.
+func RequireVerifiedEmail(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if UnverifiedUserMode == UnverifiedFull {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user := GetUser(r)
+		if user == nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(models.NewErrorResponse(
+				"Unauthorized",
+				"User not found",
+			))
+			return
+		}
+
+		if user.EmailVerified() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		safeMethod := r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+		if UnverifiedUserMode == UnverifiedReadOnly && safeMethod {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.NewErrorResponse(
+			"Email not verified",
+			"Verify your email address to use this endpoint",
+		))
+	}
+}
@@ -0,0 +1,4 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS urgent;
+ALTER TABLE tasks DROP COLUMN IF EXISTS important;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS chk_tasks_priority;
+ALTER TABLE tasks DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,7 @@
+-- 0 none, 1 low, 2 medium, 3 high, 4 urgent
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD CONSTRAINT chk_tasks_priority CHECK (priority BETWEEN 0 AND 4);
+
+-- NULL means derived: importance from the priority, urgency from the deadline
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS important BOOLEAN;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS urgent BOOLEAN;
@@ -0,0 +1,5 @@
+-- Subtasks point to the task they belong to
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_id VARCHAR(255)
+    CONSTRAINT fk_tasks_parent REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks(parent_id);
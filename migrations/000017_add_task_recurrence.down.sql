@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS occurrence;
+ALTER TABLE tasks DROP COLUMN IF EXISTS recurrence_timezone;
+ALTER TABLE tasks DROP COLUMN IF EXISTS recurrence;
@@ -0,0 +1,4 @@
+-- RFC 5545 recurrence rules; the rule lives on the open occurrence of a series
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurrence VARCHAR(255);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurrence_timezone VARCHAR(64);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS occurrence INTEGER NOT NULL DEFAULT 0;
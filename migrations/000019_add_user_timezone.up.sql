@@ -0,0 +1,2 @@
+-- IANA time zone that day boundaries and deadlines are shown in
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
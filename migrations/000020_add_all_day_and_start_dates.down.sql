@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_tasks_due_date;
+DROP INDEX IF EXISTS idx_tasks_deadline;
+
+-- All-day tasks become due at midnight UTC, undated ones when they were created
+UPDATE tasks SET deadline = COALESCE(due_date::timestamp AT TIME ZONE 'UTC', created_at) WHERE deadline IS NULL;
+ALTER TABLE tasks ALTER COLUMN deadline SET NOT NULL;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS start_date;
+ALTER TABLE tasks DROP COLUMN IF EXISTS due_date;
+ALTER TABLE tasks DROP COLUMN IF EXISTS all_day;
@@ -0,0 +1,9 @@
+-- Undated tasks have no deadline; all-day tasks are due on a civil date
+ALTER TABLE tasks ALTER COLUMN deadline DROP NOT NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS all_day BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_date DATE;
+-- Tasks are hidden from today and the backlog before their start date
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS start_date DATE;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_deadline ON tasks(deadline);
+CREATE INDEX IF NOT EXISTS idx_tasks_due_date ON tasks(due_date);
@@ -0,0 +1,3 @@
+-- Task listings are paged by user in the order of their sort column and ID
+CREATE INDEX IF NOT EXISTS idx_tasks_user_created_at ON tasks(user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_deadline ON tasks(user_id, deadline, id);
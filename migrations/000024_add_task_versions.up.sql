@@ -0,0 +1,2 @@
+-- The version of a task counts its changes, writes check it to not overwrite each other
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
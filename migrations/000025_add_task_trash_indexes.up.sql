@@ -0,0 +1,3 @@
+-- The trash lists deleted tasks by user, the retention job finds them by age
+CREATE INDEX IF NOT EXISTS idx_tasks_trash ON tasks(user_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS task_events;
+DROP FUNCTION IF EXISTS reject_task_event_change();
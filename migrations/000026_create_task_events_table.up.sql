@@ -0,0 +1,34 @@
+-- The change history of tasks. Events outlive their task, purging it from
+-- the trash leaves the history in place, so task_id has no foreign key.
+-- They are removed with the account of the user that owns the task. Task IDs
+-- can be used again after a purge, so a history is read for the owner and
+-- from the time the current task was created.
+CREATE TABLE IF NOT EXISTS task_events (
+    id SERIAL PRIMARY KEY,
+    task_id VARCHAR(255) NOT NULL,
+    user_id INTEGER NOT NULL,
+    actor_id INTEGER NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    changes JSONB,
+    request_id VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_task_events_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events(task_id, id);
+CREATE INDEX IF NOT EXISTS idx_task_events_user_id ON task_events(user_id);
+
+-- Events are never changed or deleted once written. Purging an account is
+-- the only exception; it sets task_events.purge for its transaction.
+CREATE OR REPLACE FUNCTION reject_task_event_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('task_events.purge', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'task events are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS task_events_append_only ON task_events;
+CREATE TRIGGER task_events_append_only BEFORE UPDATE OR DELETE ON task_events
+    FOR EACH ROW EXECUTE FUNCTION reject_task_event_change();
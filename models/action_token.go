@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ActionToken records a single-use token sent to a user, e.g. in an email
+// verification or password reset link. The token itself is a signed JWT
+// whose ID is the primary key here.
+type ActionToken struct {
+	ID        string    `gorm:"primaryKey;type:varchar(64)"`
+	UserID    uint      `gorm:"not null;index"`
+	Purpose   string    `gorm:"type:varchar(32);not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// AdminUser is a user as seen by administrators, with task statistics
+type AdminUser struct {
+	User
+	TaskCount          int64 `json:"task_count"`
+	CompletedTaskCount int64 `json:"completed_task_count"`
+}
+
+type AdminUsersResponse struct {
+	Users  []AdminUser `json:"users"`
+	Total  int64       `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Audit event actions
+const (
+	AuditLoginLocked    = "login.locked"
+	AuditLoginIPBlocked = "login.ip_blocked"
+	AuditLoginUnlocked  = "login.unlocked"
+
+	AuditIdentityLinked   = "identity.linked"
+	AuditIdentityUnlinked = "identity.unlinked"
+
+	AuditUserDisabled        = "user.disabled"
+	AuditUserEnabled         = "user.enabled"
+	AuditUserRoleChanged     = "user.role_changed"
+	AuditPasswordResetForced = "user.password_reset_forced"
+
+	AuditPasswordChanged   = "account.password_changed"
+	AuditEmailChanged      = "account.email_changed"
+	AuditDeletionScheduled = "account.deletion_scheduled"
+	AuditDeletionCanceled  = "account.deletion_canceled"
+)
+
+// AuditEvent is an append-only record of a security relevant event
+type AuditEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    *uint     `json:"user_id" gorm:"index"`
+	Action    string    `json:"action" gorm:"type:varchar(64);not null"`
+	IPAddress string    `json:"ip_address" gorm:"type:varchar(64)"`
+	Detail    string    `json:"detail" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Statuses of a data export
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+)
+
+// DataExport is a request for an archive with all data stored about a user.
+// The archive is built in the background and kept until ExpiresAt.
+type DataExport struct {
+	ID          string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	UserID      uint       `json:"-" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	Size        int64      `json:"size,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+type DataExportResponse struct {
+	DataExport
+	DownloadURL          string     `json:"download_url,omitempty"`
+	DownloadURLExpiresAt *time.Time `json:"download_url_expires_at,omitempty"`
+}
+
+type DataExportsResponse struct {
+	Exports []DataExport `json:"exports"`
+}
+
+// ExportedUser is the user as included in a data export, with the fields the
+// API normally hides. Credentials and 2FA secrets are never exported.
+type ExportedUser struct {
+	User
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+func NewExportedUser(user User) ExportedUser {
+	exported := ExportedUser{User: user}
+	if user.DeletedAt.Valid {
+		exported.DeletedAt = &user.DeletedAt.Time
+	}
+	return exported
+}
+
+// ExportedTask is a task as included in a data export, soft deleted ones included
+type ExportedTask struct {
+	Task
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+func NewExportedTask(task Task) ExportedTask {
+	exported := ExportedTask{Task: task, CreatedAt: task.CreatedAt, UpdatedAt: task.UpdatedAt}
+	if task.DeletedAt.Valid {
+		exported.DeletedAt = &task.DeletedAt.Time
+	}
+	return exported
+}
+
+// ExportedSession is a session as included in a data export, revoked ones included
+type ExportedSession struct {
+	Session
+	RevokedAt *time.Time `json:"revoked_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func NewExportedSession(session Session) ExportedSession {
+	return ExportedSession{Session: session, RevokedAt: session.RevokedAt, UpdatedAt: session.UpdatedAt}
+}
@@ -11,3 +11,10 @@ func NewErrorResponse(error string, message string) ErrorResponse {
 		Message: message,
 	}
 }
+
+// QueryErrorResponse points to the byte offset of a task filter expression
+// that is invalid
+type QueryErrorResponse struct {
+	ErrorResponse
+	Position int `json:"position"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// LoginAttempt counts recent failed logins for an account ("email:...") or a client address ("ip:...")
+type LoginAttempt struct {
+	Key           string    `gorm:"primaryKey;type:varchar(320)"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	LockedUntil   *time.Time
+	UpdatedAt     time.Time
+}
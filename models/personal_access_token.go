@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ScopeList is stored as a space separated string and serialized as a JSON array
+type ScopeList []string
+
+func (s ScopeList) Value() (driver.Value, error) {
+	return strings.Join(s, " "), nil
+}
+
+func (s *ScopeList) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	case nil:
+		str = ""
+	default:
+		return fmt.Errorf("cannot scan %T into ScopeList", value)
+	}
+	*s = strings.Fields(str)
+	return nil
+}
+
+func (s ScopeList) Contains(scope string) bool {
+	for _, granted := range s {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PersonalAccessToken lets scripts and integrations call the API on behalf of
+// a user without their password. Only a hash of the token is stored.
+type PersonalAccessToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(32);not null"` // first characters of the token, to recognize it in listings
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Scopes     ScopeList  `json:"scopes" gorm:"type:text;not null"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=tasks:read tasks:write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatePersonalAccessTokenResponse is the only response that contains the token itself
+type CreatePersonalAccessTokenResponse struct {
+	Token string `json:"token"`
+	PersonalAccessToken
+}
+
+type PersonalAccessTokensResponse struct {
+	Tokens []PersonalAccessToken `json:"tokens"`
+}
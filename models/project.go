@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Project is a list that tasks of a user can be organized in. Archived
+// projects keep their tasks but drop out of the daily views.
+type Project struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"-" gorm:"not null;index"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null"`
+	Color     string    `json:"color,omitempty" gorm:"type:varchar(7)"`
+	Archived  bool      `json:"archived" gorm:"not null;default:false"`
+	Position  int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+// ProjectRequest creates or updates a project. Archived and Position keep
+// their current values when left out.
+type ProjectRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Color    string `json:"color" validate:"omitempty,hexcolor"`
+	Archived *bool  `json:"archived"`
+	Position *int   `json:"position" validate:"omitempty,min=0"`
+}
+
+type ProjectsResponse struct {
+	Projects []Project `json:"projects"`
+}
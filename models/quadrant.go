@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Quadrants of the Eisenhower matrix
+const (
+	QuadrantDo        = "do"        // urgent and important
+	QuadrantSchedule  = "schedule"  // important, not urgent
+	QuadrantDelegate  = "delegate"  // urgent, not important
+	QuadrantEliminate = "eliminate" // neither urgent nor important
+)
+
+// IsImportant uses the importance set on the task, or else treats high and urgent priorities as important
+func (t *Task) IsImportant() bool {
+	if t.Important != nil {
+		return *t.Important
+	}
+	return t.Priority >= PriorityHigh
+}
+
+// IsUrgent uses the urgency set on the task, or else treats tasks that are
+// due within the urgency window (or overdue) as urgent. All-day tasks are
+// due at the end of their day in the location of now; undated tasks are
+// never urgent on their own.
+func (t *Task) IsUrgent(now time.Time, urgencyWindow time.Duration) bool {
+	if t.Urgent != nil {
+		return *t.Urgent
+	}
+	due := t.DueAt(now.Location())
+	return due != nil && due.Before(now.Add(urgencyWindow))
+}
+
+func (t *Task) Quadrant(now time.Time, urgencyWindow time.Duration) string {
+	important := t.IsImportant()
+	urgent := t.IsUrgent(now, urgencyWindow)
+
+	switch {
+	case urgent && important:
+		return QuadrantDo
+	case important:
+		return QuadrantSchedule
+	case urgent:
+		return QuadrantDelegate
+	default:
+		return QuadrantEliminate
+	}
+}
+
+// MatrixResponse groups open tasks into the quadrants of the Eisenhower matrix
+type MatrixResponse struct {
+	Do        []Task `json:"do"`
+	Schedule  []Task `json:"schedule"`
+	Delegate  []Task `json:"delegate"`
+	Eliminate []Task `json:"eliminate"`
+}
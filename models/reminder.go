@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Channels a reminder can be delivered through
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelInApp   = "in_app"
+)
+
+// Reminder statuses
+const (
+	ReminderPending  = "pending"
+	ReminderSent     = "sent"
+	ReminderFailed   = "failed"   // gave up after too many failed attempts
+	ReminderCanceled = "canceled" // the task was completed or deleted before the reminder was due
+)
+
+// Reminder notifies the user about a task, either a fixed time Before its
+// deadline or At an absolute time. FireAt is when it is due; relative
+// reminders are re-armed with a new FireAt when the deadline changes.
+type Reminder struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	TaskID      string     `json:"task_id" gorm:"type:varchar(255);not null;index"`
+	UserID      uint       `json:"-" gorm:"not null;index"`
+	Channel     string     `json:"channel" gorm:"type:varchar(20);not null"`
+	WebhookURL  string     `json:"webhook_url,omitempty" gorm:"type:text"`
+	Before      string     `json:"before,omitempty" gorm:"column:remind_before;type:varchar(32)"`
+	At          *time.Time `json:"at,omitempty" gorm:"column:remind_at"`
+	FireAt      time.Time  `json:"fire_at" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	LastError   string     `json:"last_error,omitempty" gorm:"type:text"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	LockedBy    string     `json:"-" gorm:"type:varchar(64)"`
+	LockedUntil *time.Time `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"-"`
+}
+
+// Notification is an in-app message for the user
+type Notification struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"not null;index"`
+	TaskID     *string    `json:"task_id,omitempty" gorm:"type:varchar(255)"`
+	ReminderID *uint      `json:"reminder_id,omitempty"`
+	Title      string     `json:"title" gorm:"type:varchar(255);not null"`
+	Body       string     `json:"body" gorm:"type:text"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ReminderRequest creates a reminder. Exactly one of Before (a duration
+// such as "1h" or "30m") and At has to be given.
+type ReminderRequest struct {
+	Channel    string     `json:"channel" validate:"required,oneof=email webhook in_app"`
+	Before     string     `json:"before"`
+	At         *time.Time `json:"at"`
+	WebhookURL string     `json:"webhook_url" validate:"required_if=Channel webhook,omitempty,url"`
+}
+
+type RemindersResponse struct {
+	Reminders []Reminder `json:"reminders"`
+}
+
+type NotificationsResponse struct {
+	Notifications []Notification `json:"notifications"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// SavedQuery is a named task filter expression of a user, e.g.
+// "Work this week" for "tag:work AND deadline<=2026-11-01"
+type SavedQuery struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"-" gorm:"not null;uniqueIndex:idx_saved_queries_user_name"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_saved_queries_user_name"`
+	Query     string    `json:"query" gorm:"type:text;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+type SavedQueryRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Query string `json:"query" validate:"required,max=1000"`
+}
+
+type SavedQueriesResponse struct {
+	SavedQueries []SavedQuery `json:"saved_queries"`
+}
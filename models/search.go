@@ -0,0 +1,14 @@
+package models
+
+// SearchResult is a task matching a full-text search. Title and Snippet
+// are HTML-escaped and mark the matched words with <mark> and </mark>.
+type SearchResult struct {
+	Task    Task    `json:"task"`
+	Rank    float64 `json:"rank"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+}
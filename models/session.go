@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Session represents a logged in device. Every refresh token issued for the
+// device belongs to the same session, so revoking it ends the whole family.
+type Session struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	UserID     uint       `json:"-" gorm:"not null;index"`
+	UserAgent  string     `json:"user_agent" gorm:"type:text"`
+	IPAddress  string     `json:"ip_address" gorm:"type:varchar(64)"`
+	LastUsedAt time.Time  `json:"last_used_at" gorm:"not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"-"`
+	Current    bool       `json:"current" gorm:"-"`
+}
+
+// RefreshToken stores the hash of a refresh token. Tokens are single use: a
+// token with UsedAt set that is presented again indicates it was stolen.
+type RefreshToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	SessionID string    `gorm:"type:varchar(64);not null;index"`
+	TokenHash string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type SessionsResponse struct {
+	Sessions []Session `json:"sessions"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Tag is a per-user label that can be attached to any number of tasks
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"-" gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	Name      string    `json:"name" gorm:"type:varchar(50);not null;uniqueIndex:idx_tags_user_name" validate:"required,max=50"`
+	Color     string    `json:"color,omitempty" gorm:"type:varchar(7)" validate:"omitempty,hexcolor"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+// UnmarshalJSON accepts a plain tag name as well as a tag object, so tasks
+// can be tagged with "tags": ["errand"]
+func (t *Tag) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = Tag{Name: name}
+		return nil
+	}
+
+	type tag Tag
+	var value tag
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*t = Tag(value)
+	return nil
+}
+
+type TagRequest struct {
+	Name  string `json:"name" validate:"required,max=50"`
+	Color string `json:"color" validate:"omitempty,hexcolor"`
+}
+
+type MergeTagRequest struct {
+	TargetID uint `json:"target_id" validate:"required"`
+}
+
+type TagsResponse struct {
+	Tags []Tag `json:"tags"`
+}
@@ -11,25 +11,87 @@ import (
 var validate = validator.New()
 
 type Task struct {
-	ID          string         `gorm:"primarykey;type:varchar(255)" json:"id"`
-	UserID      uint           `gorm:"not null" json:"user_id"`
-	Title       string         `gorm:"type:varchar(255);not null" json:"title" validate:"required"`
-	Description string         `gorm:"type:text" json:"description"`
-	Deadline    time.Time      `gorm:"not null" json:"deadline" validate:"required"`
-	Completed   bool           `gorm:"default:false" json:"completed"`
-	CreatedAt   time.Time      `json:"-"`
-	UpdatedAt   time.Time      `json:"-"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
-	User        User           `gorm:"foreignKey:UserID" json:"-"`
+	ID                 string         `gorm:"primarykey;type:varchar(255)" json:"id"`
+	UserID             uint           `gorm:"not null" json:"user_id"`
+	Title              string         `gorm:"type:varchar(255);not null" json:"title" validate:"required"`
+	Description        string         `gorm:"type:text" json:"description"`
+	Deadline           *time.Time     `gorm:"index" json:"deadline"`                 // timed deadline; nil for all-day and undated tasks
+	AllDay             bool           `gorm:"not null;default:false" json:"all_day"` // due on DueDate rather than at a time
+	DueDate            *Date          `gorm:"index" json:"due_date"`                 // the day an all-day task is due
+	StartDate          *Date          `json:"start_date"`                            // the task is hidden from today and the backlog before this day
+	Completed          bool           `gorm:"default:false" json:"completed"`
+	Priority           Priority       `gorm:"type:smallint;not null;default:0" json:"priority" validate:"min=0,max=4"`
+	Important          *bool          `json:"important"` // nil derives importance from the priority
+	Urgent             *bool          `json:"urgent"`    // nil derives urgency from the deadline
+	ProjectID          *uint          `gorm:"index" json:"project_id"`
+	ParentID           *string        `gorm:"type:varchar(255);index" json:"parent_id"`
+	Progress           *TaskProgress  `gorm:"-" json:"progress,omitempty"`                   // only set for tasks with subtasks
+	Recurrence         string         `gorm:"type:varchar(255)" json:"recurrence,omitempty"` // RFC 5545 RRULE
+	RecurrenceTimezone string         `gorm:"type:varchar(64)" json:"recurrence_timezone,omitempty"`
+	Occurrence         int            `gorm:"not null;default:0" json:"occurrence,omitempty"` // number within the series, starting at 1
+	Tags               []Tag          `gorm:"many2many:task_tags" json:"tags"`
+	Version            int            `gorm:"not null;default:1" json:"version"` // counts changes, the ETag of the task
+	CreatedAt          time.Time      `json:"-"`
+	UpdatedAt          time.Time      `json:"-"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
+	User               User           `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TaskProgress counts the direct subtasks of a task
+type TaskProgress struct {
+	Done    int64  `json:"done"`
+	Total   int64  `json:"total"`
+	Summary string `json:"summary"`
+}
+
+func NewTaskProgress(done, total int64) *TaskProgress {
+	return &TaskProgress{
+		Done:    done,
+		Total:   total,
+		Summary: fmt.Sprintf("%d/%d subtasks done", done, total),
+	}
 }
 
 func (t *Task) BeforeCreate(tx *gorm.DB) error {
 	if t.ID == "" {
 		t.ID = fmt.Sprintf("%d", time.Now().UnixMilli())
 	}
+	t.Version = 1
 	return nil
 }
 
 func (t *Task) Validate() error {
-	return validate.Struct(t)
+	if err := validate.Struct(t); err != nil {
+		return err
+	}
+
+	switch {
+	case t.AllDay && t.DueDate == nil:
+		return fmt.Errorf("all-day tasks need a due_date")
+	case t.AllDay && t.Deadline != nil:
+		return fmt.Errorf("all-day tasks have a due_date instead of a deadline")
+	case !t.AllDay && t.DueDate != nil:
+		return fmt.Errorf("due_date is only used by all-day tasks, use deadline instead")
+	case t.AllDay && t.StartDate != nil && t.StartDate.After(*t.DueDate):
+		return fmt.Errorf("start_date can't be after the due_date")
+	}
+	return nil
+}
+
+// Dated reports whether the task has a deadline or a due date
+func (t *Task) Dated() bool {
+	return t.Deadline != nil || t.DueDate != nil
+}
+
+// DueAt returns when the task is due: its deadline, or the end of the due
+// date of an all-day task in loc. Undated tasks return nil.
+func (t *Task) DueAt(loc *time.Location) *time.Time {
+	if t.Deadline != nil {
+		return t.Deadline
+	}
+	if t.DueDate != nil {
+		end := t.DueDate.In(loc).AddDate(0, 0, 1)
+		return &end
+	}
+	return nil
 }
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Task event actions
+const (
+	TaskCreated  = "created"
+	TaskUpdated  = "updated"
+	TaskToggled  = "toggled"
+	TaskDeleted  = "deleted"
+	TaskRestored = "restored"
+)
+
+// TaskEvent is an append-only record of a change to a task
+type TaskEvent struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	TaskID    string      `json:"task_id" gorm:"type:varchar(255);not null;index"`
+	UserID    uint        `json:"-" gorm:"not null"`        // owner of the task
+	ActorID   uint        `json:"actor_id" gorm:"not null"` // user who made the change
+	Action    string      `json:"action" gorm:"type:varchar(32);not null"`
+	Changes   TaskChanges `json:"changes,omitempty" gorm:"type:text"`
+	RequestID string      `json:"request_id,omitempty" gorm:"type:varchar(64)"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// FieldChange is the value of a task field before and after a change
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// TaskChanges maps the JSON names of task fields to their changes. It is
+// stored as JSON.
+type TaskChanges map[string]FieldChange
+
+func (c TaskChanges) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	return string(data), err
+}
+
+func (c *TaskChanges) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case nil:
+		*c = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into TaskChanges", value)
+	}
+	return json.Unmarshal(data, c)
+}
+
+type TaskHistoryResponse struct {
+	Events []TaskEvent `json:"events"`
+}
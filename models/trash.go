@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TrashedTask is a deleted task, which can be restored until it is purged
+type TrashedTask struct {
+	Task
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type TrashResponse struct {
+	Tasks []TrashedTask `json:"tasks"`
+}
@@ -7,18 +7,44 @@ import (
 	"gorm.io/gorm"
 )
 
+// Roles a user can have
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Email     string         `json:"email" gorm:"unique;not null"`
-	Password  string         `json:"-" gorm:"not null"` // "-" means this field won't be included in JSON
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                    uint           `json:"id" gorm:"primaryKey"`
+	Email                 string         `json:"email" gorm:"unique;not null"`
+	Name                  string         `json:"name" gorm:"type:varchar(100)"`
+	Timezone              string         `json:"timezone" gorm:"type:varchar(64);not null;default:UTC"` // IANA time zone for day boundaries and deadlines
+	PendingEmail          string         `json:"pending_email,omitempty" gorm:"type:varchar(255)"`      // new address waiting for confirmation
+	Password              string         `json:"-" gorm:"not null"`                                     // "-" means this field won't be included in JSON
+	EmailVerifiedAt       *time.Time     `json:"email_verified_at"`
+	TOTPSecret            string         `json:"-" gorm:"column:totp_secret;type:varchar(64)"`
+	TOTPEnabled           bool           `json:"totp_enabled" gorm:"column:totp_enabled;not null;default:false"`
+	TOTPLastStep          int64          `json:"-" gorm:"column:totp_last_step;not null;default:0"` // last accepted time step, prevents code replays
+	Role                  string         `json:"role" gorm:"type:varchar(20);not null;default:user"`
+	DisabledAt            *time.Time     `json:"disabled_at"`
+	DeletionScheduledAt   *time.Time     `json:"deletion_scheduled_at"`                                 // the account is purged after this time unless the deletion is canceled
+	PasswordResetRequired bool           `json:"password_reset_required" gorm:"not null;default:false"` // blocks logins until the password is reset
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
 }
 
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
+	Timezone string `json:"timezone" validate:"omitempty,timezone"`
 }
 
 type LoginRequest struct {
@@ -27,8 +53,10 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	User         User   `json:"user"`
 }
 
 func (u *User) HashPassword() error {
@@ -0,0 +1,119 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func assertJSON(t *testing.T, got []byte, expected string) {
+	t.Helper()
+
+	var gotValue, expectedValue interface{}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotValue, expectedValue) {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{"Replace", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"Add", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"Remove", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"Arrays Are Replaced", `{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{"Nested", `{"a":{"b":"c","d":"e"}}`, `{"a":{"b":"f","d":null}}`, `{"a":{"b":"f"}}`},
+		{"Object Into Value", `{"a":"b"}`, `{"a":{"b":null,"c":"d"}}`, `{"a":{"c":"d"}}`},
+		{"Empty", `{"a":"b"}`, `{}`, `{"a":"b"}`},
+		{"Not An Object", `{"a":"b"}`, `["c"]`, `["c"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, got, tt.expected)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{"Add Member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{"Insert Element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"Append Element", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"baz"}]`, `{"foo":["bar","baz"]}`},
+		{"Remove Member", `{"foo":"bar","baz":"qux"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"Remove Element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"Replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"Replace Element", `{"foo":["a","b","c"]}`, `[{"op":"replace","path":"/foo/1","value":"x"}]`, `{"foo":["a","x","c"]}`},
+		{"Move", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"Move Element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"Copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{"Test", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"Escaped Pointer", `{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`, `{"a/b":3}`},
+		{"Null Value", `{"foo":"bar"}`, `[{"op":"replace","path":"/foo","value":null}]`, `{"foo":null}`},
+		{"Whole Document", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":{"baz":"qux"}}]`, `{"baz":"qux"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, got, tt.expected)
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"Not An Array", `{"op":"add","path":"/a","value":1}`},
+		{"Unknown Op", `[{"op":"merge","path":"/a","value":1}]`},
+		{"Missing Value", `[{"op":"add","path":"/a"}]`},
+		{"Missing Path", `[{"op":"remove"}]`},
+		{"Relative Path", `[{"op":"remove","path":"a"}]`},
+		{"Missing Member", `[{"op":"remove","path":"/missing"}]`},
+		{"Replace Missing Member", `[{"op":"replace","path":"/missing","value":1}]`},
+		{"Missing Parent", `[{"op":"add","path":"/missing/a","value":1}]`},
+		{"Index Out Of Range", `[{"op":"add","path":"/list/3","value":1}]`},
+		{"Leading Zero", `[{"op":"remove","path":"/list/01"}]`},
+		{"Move Into Itself", `[{"op":"move","from":"/obj","path":"/obj/inner"}]`},
+		{"Add To Value", `[{"op":"add","path":"/a/b","value":1}]`},
+	}
+
+	doc := []byte(`{"a":1,"list":[1,2],"obj":{}}`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Apply(doc, []byte(tt.patch)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	_, err := Apply(doc, []byte(`[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`))
+	if !errors.Is(err, ErrTestFailed) {
+		t.Errorf("expected the test to fail, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package recurrence
+
+import (
+	"sort"
+	"time"
+)
+
+// Next returns the occurrence following prev, which is occurrence number n
+// of the series (1 for the first one). Occurrences keep the wall clock time
+// of prev in loc, so they don't shift when daylight saving time starts or
+// ends. The second result is false when the series is over.
+func (r *Rule) Next(prev time.Time, n int, loc *time.Location) (time.Time, bool) {
+	if r.Count > 0 && n >= r.Count {
+		return time.Time{}, false
+	}
+
+	local := prev.In(loc)
+	for period := 0; period < maxPeriods; period++ {
+		for _, candidate := range r.candidates(local, period) {
+			if !candidate.After(prev) {
+				continue
+			}
+			if !r.Until.IsZero() && candidate.After(r.Until) {
+				return time.Time{}, false
+			}
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// candidates returns the occurrences of the period'th period after the one
+// of start, in order
+func (r *Rule) candidates(start time.Time, period int) []time.Time {
+	year, month, day := start.Date()
+	hour, min, sec := start.Clock()
+	loc := start.Location()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, loc)
+	}
+
+	switch r.Freq {
+	case Daily:
+		return []time.Time{at(year, month, day+period*r.Interval)}
+
+	case Weekly:
+		// Weeks start on Monday
+		monday := day - (int(start.Weekday())+6)%7 + period*r.Interval*7
+
+		offsets := []int{(int(start.Weekday()) + 6) % 7}
+		if len(r.ByDay) > 0 {
+			offsets = offsets[:0]
+			for _, byDay := range r.ByDay {
+				offsets = append(offsets, (int(byDay.Weekday)+6)%7)
+			}
+			sort.Ints(offsets)
+		}
+
+		var candidates []time.Time
+		for _, offset := range offsets {
+			candidates = append(candidates, at(year, month, monday+offset))
+		}
+		return candidates
+
+	default:
+		first := time.Date(year, month+time.Month(period*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		days := r.monthDays(first, day)
+
+		candidates := make([]time.Time, len(days))
+		for i, day := range days {
+			candidates[i] = at(first.Year(), first.Month(), day)
+		}
+		return candidates
+	}
+}
+
+// monthDays returns the days of the month starting at first that match a
+// monthly rule, in order. Without BYDAY or BYMONTHDAY the rule repeats on
+// startDay; months that are too short for it are skipped.
+func (r *Rule) monthDays(first time.Time, startDay int) []int {
+	length := first.AddDate(0, 1, -1).Day()
+	matches := map[int]bool{}
+
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, day := range r.ByMonthDay {
+			if day < 0 {
+				day = length + day + 1
+			}
+			if day >= 1 && day <= length {
+				matches[day] = true
+			}
+		}
+
+	case len(r.ByDay) > 0:
+		for _, byDay := range r.ByDay {
+			firstMatch := 1 + (int(byDay.Weekday)-int(first.Weekday())+7)%7
+			var days []int
+			for day := firstMatch; day <= length; day += 7 {
+				days = append(days, day)
+			}
+
+			switch {
+			case byDay.N == 0:
+				for _, day := range days {
+					matches[day] = true
+				}
+			case byDay.N > 0 && byDay.N <= len(days):
+				matches[days[byDay.N-1]] = true
+			case byDay.N < 0 && -byDay.N <= len(days):
+				matches[days[len(days)+byDay.N]] = true
+			}
+		}
+
+	default:
+		if startDay <= length {
+			matches[startDay] = true
+		}
+	}
+
+	days := make([]int, 0, len(matches))
+	for day := range matches {
+		days = append(days, day)
+	}
+	sort.Ints(days)
+	return days
+}
@@ -0,0 +1,175 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule      string
+		canonical string
+		wantErr   bool
+	}{
+		{rule: "FREQ=DAILY", canonical: "FREQ=DAILY"},
+		{rule: "RRULE:freq=weekly;byday=mo,th;interval=2", canonical: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"},
+		{rule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", canonical: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20261231", canonical: "FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20261231T235959Z"},
+		{rule: "", wantErr: true},
+		{rule: "INTERVAL=2", wantErr: true},
+		{rule: "FREQ=YEARLY", wantErr: true},
+		{rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=2;UNTIL=20260101", wantErr: true},
+		{rule: "FREQ=DAILY;BYDAY=MO", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYMONTHDAY=1", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{rule: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+		{rule: "FREQ=DAILY;BYHOUR=9", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rule.String() != tt.canonical {
+				t.Errorf("got %q want %q", rule.String(), tt.canonical)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := time.UTC
+	tests := []struct {
+		name     string
+		rule     string
+		prev     time.Time
+		n        int
+		expected []time.Time // following occurrences
+		ends     bool        // whether the series ends after them
+	}{
+		{
+			name:     "Daily",
+			rule:     "FREQ=DAILY;INTERVAL=2",
+			prev:     time.Date(2026, 1, 30, 9, 0, 0, 0, utc),
+			expected: []time.Time{time.Date(2026, 2, 1, 9, 0, 0, 0, utc), time.Date(2026, 2, 3, 9, 0, 0, 0, utc)},
+		},
+		{
+			name: "Weekly On Weekdays",
+			rule: "FREQ=WEEKLY;BYDAY=MO,TH;INTERVAL=2",
+			prev: time.Date(2026, 3, 2, 8, 0, 0, 0, utc), // Monday
+			expected: []time.Time{
+				time.Date(2026, 3, 5, 8, 0, 0, 0, utc),
+				time.Date(2026, 3, 16, 8, 0, 0, 0, utc),
+				time.Date(2026, 3, 19, 8, 0, 0, 0, utc),
+			},
+		},
+		{
+			name:     "Weekly Defaults To The Weekday",
+			rule:     "FREQ=WEEKLY",
+			prev:     time.Date(2026, 3, 4, 8, 0, 0, 0, utc), // Wednesday
+			expected: []time.Time{time.Date(2026, 3, 11, 8, 0, 0, 0, utc)},
+		},
+		{
+			name: "Monthly Skips Short Months",
+			rule: "FREQ=MONTHLY",
+			prev: time.Date(2026, 1, 31, 10, 0, 0, 0, utc),
+			expected: []time.Time{
+				time.Date(2026, 3, 31, 10, 0, 0, 0, utc),
+				time.Date(2026, 5, 31, 10, 0, 0, 0, utc),
+			},
+		},
+		{
+			name:     "Monthly Last Day",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=-1",
+			prev:     time.Date(2026, 1, 31, 10, 0, 0, 0, utc),
+			expected: []time.Time{time.Date(2026, 2, 28, 10, 0, 0, 0, utc), time.Date(2026, 3, 31, 10, 0, 0, 0, utc)},
+		},
+		{
+			name:     "Monthly Last Friday",
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR",
+			prev:     time.Date(2026, 1, 30, 17, 0, 0, 0, utc),
+			expected: []time.Time{time.Date(2026, 2, 27, 17, 0, 0, 0, utc), time.Date(2026, 3, 27, 17, 0, 0, 0, utc)},
+		},
+		{
+			name: "Count Reached",
+			rule: "FREQ=DAILY;COUNT=3",
+			prev: time.Date(2026, 1, 3, 9, 0, 0, 0, utc),
+			n:    3,
+			ends: true,
+		},
+		{
+			name:     "Until Reached",
+			rule:     "FREQ=DAILY;UNTIL=20260104T090000Z",
+			prev:     time.Date(2026, 1, 3, 9, 0, 0, 0, utc),
+			expected: []time.Time{time.Date(2026, 1, 4, 9, 0, 0, 0, utc)},
+			ends:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			n := tt.n
+			if n == 0 {
+				n = 1
+			}
+			prev := tt.prev
+			for _, want := range tt.expected {
+				next, ok := rule.Next(prev, n, utc)
+				if !ok || !next.Equal(want) {
+					t.Fatalf("after %v: got %v (%v) want %v", prev, next, ok, want)
+				}
+				prev, n = next, n+1
+			}
+
+			if next, ok := rule.Next(prev, n, utc); ok == tt.ends {
+				t.Errorf("after %v: got %v (%v), expected the series to end: %v", prev, next, ok, tt.ends)
+			}
+		})
+	}
+}
+
+func TestNextKeepsWallClockAcrossDST(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	rule, err := Parse("FREQ=DAILY")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Daylight saving time starts on March 29, 2026 in Berlin
+	prev := time.Date(2026, 3, 28, 9, 0, 0, 0, berlin)
+	next, ok := rule.Next(prev.UTC(), 1, berlin)
+	if !ok {
+		t.Fatal("expected a next occurrence")
+	}
+	if local := next.In(berlin); local.Hour() != 9 || local.Day() != 29 {
+		t.Errorf("expected 9:00 on March 29 in Berlin, got %v", local)
+	}
+	if next.Sub(prev) != 23*time.Hour {
+		t.Errorf("expected the day to be 23 hours long, got %v", next.Sub(prev))
+	}
+}
@@ -0,0 +1,172 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"just-do-it-api/config"
+	"just-do-it-api/database"
+	"just-do-it-api/mailer"
+	"just-do-it-api/models"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Delivery is a due reminder together with its task and user
+type Delivery struct {
+	Reminder *models.Reminder
+	Task     *models.Task
+	User     *models.User
+}
+
+// Subject is the headline of the reminder
+func (d Delivery) Subject() string {
+	return "Reminder: " + d.Task.Title
+}
+
+// Text is the message of the reminder. Deadlines are shown in the time zone of the user.
+func (d Delivery) Text() string {
+	switch {
+	case d.Task.Deadline != nil:
+		loc, err := time.LoadLocation(d.User.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		return fmt.Sprintf("Your task %q is due %s.", d.Task.Title, d.Task.Deadline.In(loc).Format(time.RFC1123))
+	case d.Task.DueDate != nil:
+		return fmt.Sprintf("Your task %q is due on %s.", d.Task.Title, d.Task.DueDate)
+	default:
+		return fmt.Sprintf("Don't forget your task %q.", d.Task.Title)
+	}
+}
+
+// Channel delivers reminders. An error makes the scheduler try again later.
+type Channel interface {
+	Deliver(ctx context.Context, d Delivery) error
+}
+
+// EmailChannel sends reminders with the mailer, which writes them to the
+// outbox unless SMTP is configured
+type EmailChannel struct {
+	Mailer mailer.Mailer
+}
+
+func (c *EmailChannel) Deliver(ctx context.Context, d Delivery) error {
+	return c.Mailer.Send(ctx, mailer.Message{
+		To:      d.User.Email,
+		Subject: d.Subject(),
+		Body:    d.Text() + "\n",
+	})
+}
+
+// InAppChannel stores reminders as notifications the user can list in the app
+type InAppChannel struct {
+	DB database.Database
+}
+
+func (c *InAppChannel) Deliver(ctx context.Context, d Delivery) error {
+	return c.DB.Create(&models.Notification{
+		UserID:     d.User.ID,
+		TaskID:     &d.Task.ID,
+		ReminderID: &d.Reminder.ID,
+		Title:      d.Subject(),
+		Body:       d.Text(),
+	}).Error
+}
+
+// WebhookPayload is the JSON body posted to reminder webhooks
+type WebhookPayload struct {
+	Event      string      `json:"event"`
+	ReminderID uint        `json:"reminder_id"`
+	FireAt     time.Time   `json:"fire_at"`
+	Task       models.Task `json:"task"`
+}
+
+// WebhookChannel posts reminders to the URL of the reminder. With a Secret
+// the body is signed in the X-Reminder-Signature header as
+// "sha256=<hex HMAC-SHA256 of the body>".
+type WebhookChannel struct {
+	Client *http.Client
+	Secret []byte
+}
+
+// NewWebhookChannel returns a webhook channel that refuses to call private
+// and loopback addresses unless REMINDER_WEBHOOK_ALLOW_PRIVATE is set, so
+// webhooks can't be used to reach the internal network
+func NewWebhookChannel() *WebhookChannel {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !config.Bool("REMINDER_WEBHOOK_ALLOW_PRIVATE", false) {
+		dialer.Control = publicOnly
+	}
+
+	return &WebhookChannel{
+		Client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, DialContext: dialer.DialContext},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		Secret: []byte(config.String("REMINDER_WEBHOOK_SECRET", "")),
+	}
+}
+
+// publicOnly runs after name resolution, so it also catches host names
+// that point to internal addresses
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+	return nil
+}
+
+func (c *WebhookChannel) Deliver(ctx context.Context, d Delivery) error {
+	body, err := json.Marshal(WebhookPayload{
+		Event:      "task.reminder",
+		ReminderID: d.Reminder.ID,
+		FireAt:     d.Reminder.FireAt,
+		Task:       *d.Task,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Reminder.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.Secret) > 0 {
+		mac := hmac.New(sha256.New, c.Secret)
+		mac.Write(body)
+		req.Header.Set("X-Reminder-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
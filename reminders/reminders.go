@@ -0,0 +1,125 @@
+// Package reminders schedules task reminders and delivers them through
+// email, webhooks and in-app notifications.
+package reminders
+
+import (
+	"errors"
+	"fmt"
+	"just-do-it-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ParseBefore parses the offset of a relative reminder, e.g. "1h" or "30m"
+func ParseBefore(before string) (time.Duration, error) {
+	d, err := time.ParseDuration(before)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid reminder offset %q, use a positive duration such as \"1h\" or \"30m\"", before)
+	}
+	return d, nil
+}
+
+// ErrNoDeadline is returned for relative reminders of tasks without a
+// deadline or due date
+var ErrNoDeadline = errors.New("relative reminders need a task with a deadline or due date")
+
+// FireAt returns when a reminder for a task due at due, as returned by
+// Task.DueAt, is due
+func FireAt(reminder *models.Reminder, due *time.Time) (time.Time, error) {
+	if reminder.Before == "" {
+		if reminder.At == nil {
+			return time.Time{}, fmt.Errorf("reminder has neither an offset nor a time")
+		}
+		return *reminder.At, nil
+	}
+
+	before, err := ParseBefore(reminder.Before)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if due == nil {
+		return time.Time{}, ErrNoDeadline
+	}
+	return due.Add(-before), nil
+}
+
+// Rearm recomputes the relative reminders of a task after its deadline or due
+// date changed. All-day tasks are due at the end of their due date in loc.
+// The reminders are pending again, even if they were already sent for the
+// old time, and a delivery that is in progress won't mark them sent. Undated
+// tasks have their relative reminders canceled.
+func Rearm(tx *gorm.DB, task *models.Task, loc *time.Location) error {
+	due := task.DueAt(loc)
+	if due == nil {
+		return tx.Where("task_id = ? AND remind_before <> ''", task.ID).
+			Model(&models.Reminder{}).
+			Updates(map[string]interface{}{
+				"status":       models.ReminderCanceled,
+				"locked_by":    "",
+				"locked_until": nil,
+			}).Error
+	}
+
+	var relative []models.Reminder
+	if err := tx.Where("task_id = ? AND remind_before <> ''", task.ID).Find(&relative).Error; err != nil {
+		return err
+	}
+
+	for _, reminder := range relative {
+		fireAt, err := FireAt(&reminder, due)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&reminder).Updates(map[string]interface{}{
+			"fire_at":      fireAt,
+			"status":       models.ReminderPending,
+			"attempts":     0,
+			"last_error":   "",
+			"sent_at":      nil,
+			"locked_by":    "",
+			"locked_until": nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CopyRelative gives the next occurrence of a recurring task the relative
+// reminders of the previous one, due relative to its deadline or the end of
+// its due date in loc
+func CopyRelative(tx *gorm.DB, from string, to *models.Task, loc *time.Location) error {
+	due := to.DueAt(loc)
+	if due == nil {
+		return nil
+	}
+
+	var relative []models.Reminder
+	if err := tx.Where("task_id = ? AND remind_before <> ''", from).Find(&relative).Error; err != nil {
+		return err
+	}
+
+	for _, reminder := range relative {
+		fireAt, err := FireAt(&reminder, due)
+		if err != nil {
+			return err
+		}
+
+		copied := models.Reminder{
+			TaskID:     to.ID,
+			UserID:     reminder.UserID,
+			Channel:    reminder.Channel,
+			WebhookURL: reminder.WebhookURL,
+			Before:     reminder.Before,
+			FireAt:     fireAt,
+			Status:     models.ReminderPending,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package reminders
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"just-do-it-api/config"
+	"just-do-it-api/database"
+	"just-do-it-api/models"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scheduler delivers due reminders. Several instances can poll the same
+// database: a reminder is claimed with a conditional update before it is
+// delivered, so only one of them sends it.
+type Scheduler struct {
+	DB       database.Database
+	Channels map[string]Channel
+
+	// Instance identifies this scheduler in the locks it takes
+	Instance string
+
+	// BatchSize is how many due reminders one poll looks at
+	BatchSize int
+
+	// LockTTL is how long a claimed reminder stays locked. If an instance
+	// dies while delivering, another one picks the reminder up afterwards.
+	LockTTL time.Duration
+
+	// MaxAttempts is how often a failing delivery is tried before the reminder is marked failed
+	MaxAttempts int
+}
+
+// NewScheduler returns a scheduler with the email, webhook and in-app channels
+func NewScheduler(db database.Database, channels map[string]Channel) *Scheduler {
+	return &Scheduler{
+		DB:          db,
+		Channels:    channels,
+		Instance:    instanceID(),
+		BatchSize:   config.Int("REMINDER_BATCH_SIZE", 100),
+		LockTTL:     config.Duration("REMINDER_LOCK_TTL", 5*time.Minute),
+		MaxAttempts: config.Int("REMINDER_MAX_ATTEMPTS", 5),
+	}
+}
+
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "scheduler"
+	}
+
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return host + "-" + hex.EncodeToString(b)
+}
+
+// due matches pending reminders that aren't locked by another instance or waiting for a retry
+const due = "status = ? AND fire_at <= ? AND (locked_until IS NULL OR locked_until < ?)"
+
+// Poll delivers the reminders that are due now. It is meant to be run with jobs.Every.
+func (s *Scheduler) Poll(ctx context.Context) error {
+	now := time.Now()
+
+	var reminders []models.Reminder
+	err := s.DB.Where(due, models.ReminderPending, now, now).
+		Order("fire_at").
+		Limit(s.BatchSize).
+		Find(&reminders).Error
+	if err != nil {
+		return err
+	}
+
+	for _, reminder := range reminders {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		claimed, err := s.claim(&reminder, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.deliver(ctx, &reminder); err != nil {
+			log.Printf("Reminder %d: %v", reminder.ID, err)
+		}
+	}
+	return nil
+}
+
+// claim locks the reminder for this instance; it returns false when
+// another instance was faster
+func (s *Scheduler) claim(reminder *models.Reminder, now time.Time) (bool, error) {
+	result := s.DB.Where("id = ? AND "+due, reminder.ID, models.ReminderPending, now, now).
+		Model(&models.Reminder{}).
+		Updates(map[string]interface{}{
+			"locked_by":    s.Instance,
+			"locked_until": now.Add(s.LockTTL),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (s *Scheduler) deliver(ctx context.Context, reminder *models.Reminder) error {
+	var task models.Task
+	err := s.DB.Where("id = ?", reminder.TaskID).First(&task).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && task.Completed) {
+		return s.release(reminder, map[string]interface{}{"status": models.ReminderCanceled})
+	}
+	if err != nil {
+		return s.retry(reminder, err)
+	}
+
+	var user models.User
+	if err := s.DB.Where("id = ?", reminder.UserID).First(&user).Error; err != nil {
+		return s.retry(reminder, err)
+	}
+
+	channel, ok := s.Channels[reminder.Channel]
+	if !ok {
+		return s.release(reminder, map[string]interface{}{
+			"status":     models.ReminderFailed,
+			"last_error": fmt.Sprintf("unknown channel %q", reminder.Channel),
+		})
+	}
+
+	// Give up well before the lock expires, so no other instance sends the reminder meanwhile
+	deliverCtx, cancel := context.WithTimeout(ctx, s.LockTTL/2)
+	defer cancel()
+	if err := channel.Deliver(deliverCtx, Delivery{Reminder: reminder, Task: &task, User: &user}); err != nil {
+		return s.retry(reminder, err)
+	}
+
+	return s.release(reminder, map[string]interface{}{
+		"status":  models.ReminderSent,
+		"sent_at": time.Now(),
+	})
+}
+
+// retry records a failed attempt and keeps the reminder locked until the
+// next one, waiting longer after every failure
+func (s *Scheduler) retry(reminder *models.Reminder, cause error) error {
+	attempts := reminder.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":     attempts,
+		"last_error":   cause.Error(),
+		"locked_until": time.Now().Add(time.Duration(attempts*attempts) * time.Minute),
+	}
+	if attempts >= s.MaxAttempts {
+		updates["status"] = models.ReminderFailed
+		updates["locked_until"] = nil
+	}
+
+	if err := s.release(reminder, updates); err != nil {
+		return err
+	}
+	return fmt.Errorf("delivery attempt %d failed: %v", attempts, cause)
+}
+
+// release applies the outcome of a delivery and unlocks the reminder,
+// unless updates keep it locked for a retry. It changes nothing if the
+// reminder was re-armed in the meantime.
+func (s *Scheduler) release(reminder *models.Reminder, updates map[string]interface{}) error {
+	updates["locked_by"] = ""
+	if _, ok := updates["locked_until"]; !ok {
+		updates["locked_until"] = nil
+	}
+	return s.DB.Where("id = ? AND locked_by = ?", reminder.ID, s.Instance).
+		Model(&models.Reminder{}).
+		Updates(updates).Error
+}
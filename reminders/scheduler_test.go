@@ -0,0 +1,279 @@
+package reminders
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"just-do-it-api/database"
+	"just-do-it-api/mailer"
+	"just-do-it-api/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeChannel records deliveries and fails with err when it is set
+type fakeChannel struct {
+	delivered []uint
+	err       error
+	during    func(d Delivery)
+}
+
+func (c *fakeChannel) Deliver(ctx context.Context, d Delivery) error {
+	if c.during != nil {
+		c.during(d)
+	}
+	if c.err != nil {
+		return c.err
+	}
+	c.delivered = append(c.delivered, d.Reminder.ID)
+	return nil
+}
+
+func setup(t *testing.T) (database.Database, *models.Task) {
+	t.Helper()
+
+	db := database.NewMockDB()
+	user := models.User{Email: "reminders@example.com", Password: "hash"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	task := models.Task{ID: "remind-me", UserID: user.ID, Title: "Pay rent", Deadline: timePtr(time.Now().Add(time.Hour))}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, &task
+}
+
+func addReminder(t *testing.T, db database.Database, task *models.Task, fireAt time.Time) *models.Reminder {
+	t.Helper()
+
+	reminder := models.Reminder{
+		TaskID:  task.ID,
+		UserID:  task.UserID,
+		Channel: models.ChannelInApp,
+		Before:  task.Deadline.Sub(fireAt).String(),
+		FireAt:  fireAt,
+		Status:  models.ReminderPending,
+	}
+	if err := db.Create(&reminder).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &reminder
+}
+
+func newScheduler(db database.Database, channel Channel, instance string) *Scheduler {
+	return &Scheduler{
+		DB:          db,
+		Channels:    map[string]Channel{models.ChannelInApp: channel},
+		Instance:    instance,
+		BatchSize:   10,
+		LockTTL:     time.Minute,
+		MaxAttempts: 2,
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func reload(t *testing.T, db database.Database, id uint) models.Reminder {
+	t.Helper()
+
+	var reminder models.Reminder
+	if err := db.First(&reminder, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return reminder
+}
+
+func TestPollDeliversDueRemindersOnce(t *testing.T) {
+	db, task := setup(t)
+	due := addReminder(t, db, task, time.Now().Add(-time.Minute))
+	later := addReminder(t, db, task, time.Now().Add(30*time.Minute))
+
+	channel := &fakeChannel{}
+	first := newScheduler(db, channel, "first")
+	second := newScheduler(db, channel, "second")
+
+	for _, scheduler := range []*Scheduler{first, second, first} {
+		if err := scheduler.Poll(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(channel.delivered) != 1 || channel.delivered[0] != due.ID {
+		t.Fatalf("expected only reminder %d to be delivered once, got %v", due.ID, channel.delivered)
+	}
+	if got := reload(t, db, due.ID); got.Status != models.ReminderSent || got.SentAt == nil || got.LockedBy != "" {
+		t.Errorf("expected the due reminder to be sent and unlocked, got %+v", got)
+	}
+	if got := reload(t, db, later.ID); got.Status != models.ReminderPending {
+		t.Errorf("expected the later reminder to stay pending, got %s", got.Status)
+	}
+}
+
+func TestPollSkipsRemindersLockedByAnotherInstance(t *testing.T) {
+	db, task := setup(t)
+	reminder := addReminder(t, db, task, time.Now().Add(-time.Minute))
+
+	first := newScheduler(db, &fakeChannel{}, "first")
+	claimed, err := first.claim(reminder, time.Now())
+	if err != nil || !claimed {
+		t.Fatalf("expected to claim the reminder, got %v (%v)", claimed, err)
+	}
+
+	channel := &fakeChannel{}
+	if err := newScheduler(db, channel, "second").Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.delivered) != 0 {
+		t.Errorf("expected the locked reminder not to be delivered, got %v", channel.delivered)
+	}
+}
+
+func TestPollRetriesFailedDeliveries(t *testing.T) {
+	db, task := setup(t)
+	reminder := addReminder(t, db, task, time.Now().Add(-time.Minute))
+
+	scheduler := newScheduler(db, &fakeChannel{err: errors.New("unavailable")}, "first")
+	if err := scheduler.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := reload(t, db, reminder.ID)
+	if got.Status != models.ReminderPending || got.Attempts != 1 || got.LastError != "unavailable" {
+		t.Fatalf("expected a pending reminder with one failed attempt, got %+v", got)
+	}
+	if got.LockedUntil == nil || !got.LockedUntil.After(time.Now()) {
+		t.Fatalf("expected the retry to wait, got %v", got.LockedUntil)
+	}
+
+	// The last attempt gives up
+	past := time.Now().Add(-time.Second)
+	db.Where("id = ?", reminder.ID).Model(&models.Reminder{}).Update("locked_until", past)
+	if err := scheduler.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := reload(t, db, reminder.ID); got.Status != models.ReminderFailed || got.Attempts != 2 {
+		t.Errorf("expected the reminder to fail after two attempts, got %+v", got)
+	}
+}
+
+func TestPollCancelsRemindersOfCompletedTasks(t *testing.T) {
+	db, task := setup(t)
+	reminder := addReminder(t, db, task, time.Now().Add(-time.Minute))
+	db.Where("id = ?", task.ID).Model(&models.Task{}).Update("completed", true)
+
+	channel := &fakeChannel{}
+	if err := newScheduler(db, channel, "first").Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.delivered) != 0 {
+		t.Errorf("expected no delivery, got %v", channel.delivered)
+	}
+	if got := reload(t, db, reminder.ID); got.Status != models.ReminderCanceled {
+		t.Errorf("expected the reminder to be canceled, got %s", got.Status)
+	}
+}
+
+func TestRearmDuringDeliveryKeepsReminderPending(t *testing.T) {
+	db, task := setup(t)
+	reminder := addReminder(t, db, task, time.Now().Add(-time.Minute))
+
+	newDeadline := time.Now().Add(24 * time.Hour)
+	channel := &fakeChannel{during: func(d Delivery) {
+		moved := *task
+		moved.Deadline = &newDeadline
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return Rearm(tx, &moved, time.UTC)
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	}}
+	if err := newScheduler(db, channel, "first").Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := reload(t, db, reminder.ID)
+	if got.Status != models.ReminderPending {
+		t.Fatalf("expected the re-armed reminder to stay pending, got %s", got.Status)
+	}
+	if want := newDeadline.Add(-time.Minute - time.Hour); got.FireAt.Sub(want).Abs() > time.Second {
+		t.Errorf("expected the reminder to fire at %v, got %v", want, got.FireAt)
+	}
+}
+
+func TestWebhookChannel(t *testing.T) {
+	secret := []byte("webhook secret")
+	received := make(chan WebhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		if r.Header.Get("X-Reminder-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var payload WebhookPayload
+		json.Unmarshal(body, &payload)
+		received <- payload
+	}))
+	defer server.Close()
+
+	task := &models.Task{ID: "hooked", Title: "Call back"}
+	delivery := Delivery{
+		Reminder: &models.Reminder{ID: 7, WebhookURL: server.URL},
+		Task:     task,
+		User:     &models.User{},
+	}
+
+	if err := (&WebhookChannel{Client: server.Client(), Secret: secret}).Deliver(context.Background(), delivery); err != nil {
+		t.Fatal(err)
+	}
+	payload := <-received
+	if payload.Event != "task.reminder" || payload.ReminderID != 7 || payload.Task.ID != "hooked" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	// A wrong signature is answered with 401, which makes the delivery fail
+	if err := (&WebhookChannel{Client: server.Client(), Secret: []byte("other")}).Deliver(context.Background(), delivery); err == nil {
+		t.Error("expected the delivery to fail")
+	}
+
+	// Loopback addresses are refused by default
+	if err := NewWebhookChannel().Deliver(context.Background(), delivery); err == nil {
+		t.Error("expected the webhook to a loopback address to be refused")
+	}
+}
+
+func TestEmailChannelWritesToOutbox(t *testing.T) {
+	outbox := &mailer.OutboxMailer{Dir: t.TempDir()}
+	delivery := Delivery{
+		Reminder: &models.Reminder{ID: 1},
+		Task:     &models.Task{Title: "Renew passport", Deadline: timePtr(time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC))},
+		User:     &models.User{Email: "owner@example.com"},
+	}
+
+	if err := (&EmailChannel{Mailer: outbox}).Deliver(context.Background(), delivery); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := outbox.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].To != "owner@example.com" || messages[0].Subject != "Reminder: Renew passport" {
+		t.Errorf("unexpected messages %+v", messages)
+	}
+}
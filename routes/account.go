@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"just-do-it-api/handlers"
+	"just-do-it-api/middleware"
+	"net/http"
+	"strings"
+)
+
+func RegisterAccountRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/me", middleware.Logger(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetMe(w, r)
+		case http.MethodPatch:
+			middleware.RequireSession(handlers.UpdateMe)(w, r)
+		case http.MethodDelete:
+			middleware.RequireSession(handlers.DeleteMe)(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	})))
+
+	mux.HandleFunc("/v1/me/password", middleware.Logger(middleware.AuthMiddleware(middleware.RequireSession(requireMethod(http.MethodPost, handlers.ChangePassword)))))
+	mux.HandleFunc("/v1/me/email", middleware.Logger(middleware.AuthMiddleware(middleware.RequireSession(requireMethod(http.MethodPost, handlers.ChangeEmail)))))
+	mux.HandleFunc("/v1/me/email/confirm", middleware.Logger(requireMethod(http.MethodPost, handlers.ConfirmEmailChange)))
+	mux.HandleFunc("/v1/me/cancel-deletion", middleware.Logger(middleware.AuthMiddleware(middleware.RequireSession(requireMethod(http.MethodPost, handlers.CancelAccountDeletion)))))
+
+	// Data export
+	mux.HandleFunc("/v1/me/export", middleware.Logger(middleware.AuthMiddleware(middleware.RequireSession(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetDataExports(w, r)
+		case http.MethodPost:
+			handlers.CreateDataExport(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	}))))
+	mux.HandleFunc("/v1/me/export/", middleware.Logger(func(w http.ResponseWriter, r *http.Request) {
+		// Downloads are authorized by the signature in the URL
+		if strings.HasSuffix(r.URL.Path, "/download") {
+			requireMethod(http.MethodGet, handlers.DownloadDataExport)(w, r)
+			return
+		}
+		middleware.AuthMiddleware(middleware.RequireSession(requireMethod(http.MethodGet, handlers.GetDataExport)))(w, r)
+	}))
+}
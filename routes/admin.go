@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"just-do-it-api/handlers"
+	"just-do-it-api/middleware"
+	"just-do-it-api/models"
+	"net/http"
+	"strings"
+)
+
+// adminOnly requires a logged in administrator. Personal access tokens are not accepted.
+func adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return middleware.Logger(middleware.AuthMiddleware(middleware.RequireSession(middleware.RequireRole(models.RoleAdmin, next))))
+}
+
+func RegisterAdminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/admin/users", adminOnly(requireMethod(http.MethodGet, handlers.AdminGetUsers)))
+
+	mux.HandleFunc("/v1/admin/users/", adminOnly(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/admin/users/"), "/")
+		if parts[0] == "" || len(parts) > 2 {
+			http.NotFound(w, r)
+			return
+		}
+
+		action := ""
+		if len(parts) == 2 {
+			action = parts[1]
+		}
+
+		switch action {
+		case "":
+			requireMethod(http.MethodGet, handlers.AdminGetUser)(w, r)
+		case "disable":
+			requireMethod(http.MethodPost, handlers.AdminDisableUser)(w, r)
+		case "enable":
+			requireMethod(http.MethodPost, handlers.AdminEnableUser)(w, r)
+		case "force-password-reset":
+			requireMethod(http.MethodPost, handlers.AdminForcePasswordReset)(w, r)
+		case "role":
+			requireMethod(http.MethodPut, handlers.AdminUpdateUserRole)(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
@@ -2,10 +2,95 @@ package routes
 
 import (
 	"just-do-it-api/handlers"
+	"just-do-it-api/middleware"
 	"net/http"
+	"strings"
 )
 
 func RegisterAuthRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/.well-known/jwks.json", handlers.JWKS)
+
 	mux.HandleFunc("/api/auth/register", handlers.Register)
 	mux.HandleFunc("/api/auth/login", handlers.Login)
+	mux.HandleFunc("/api/auth/login/mfa", handlers.LoginMFA)
+	mux.HandleFunc("/api/auth/refresh", handlers.Refresh)
+	mux.HandleFunc("/api/auth/logout", middleware.AuthMiddleware(middleware.RequireSession(handlers.Logout)))
+
+	// OpenID Connect login and linked identities
+	mux.HandleFunc("/api/auth/oidc/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/login") && r.Method == http.MethodGet:
+			handlers.OIDCLogin(w, r)
+		case strings.HasSuffix(r.URL.Path, "/callback") && r.Method == http.MethodGet:
+			handlers.OIDCCallback(w, r)
+		case strings.HasSuffix(r.URL.Path, "/link") && r.Method == http.MethodPost:
+			middleware.AuthMiddleware(middleware.RequireSession(handlers.OIDCLink))(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("/api/auth/identities", middleware.AuthMiddleware(middleware.RequireSession(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.GetIdentities(w, r)
+	})))
+	mux.HandleFunc("/api/auth/identities/", middleware.AuthMiddleware(middleware.RequireSession(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.UnlinkIdentity(w, r)
+	})))
+
+	// Email verification and password reset
+	mux.HandleFunc("/api/auth/verify-email", handlers.VerifyEmail)
+	mux.HandleFunc("/api/auth/verify-email/resend", middleware.AuthMiddleware(middleware.RequireSession(handlers.ResendVerificationEmail)))
+	mux.HandleFunc("/api/auth/forgot-password", handlers.ForgotPassword)
+	mux.HandleFunc("/api/auth/reset-password", handlers.ResetPassword)
+
+	// TOTP two-factor authentication
+	mux.HandleFunc("/api/auth/2fa/enroll", middleware.AuthMiddleware(middleware.RequireSession(handlers.EnrollTOTP)))
+	mux.HandleFunc("/api/auth/2fa/confirm", middleware.AuthMiddleware(middleware.RequireSession(handlers.ConfirmTOTP)))
+	mux.HandleFunc("/api/auth/2fa/disable", middleware.AuthMiddleware(middleware.RequireSession(handlers.DisableTOTP)))
+	mux.HandleFunc("/api/auth/2fa/recovery-codes", middleware.AuthMiddleware(middleware.RequireSession(handlers.RegenerateRecoveryCodes)))
+
+	// Session (device) management
+	mux.HandleFunc("/api/auth/sessions", middleware.AuthMiddleware(middleware.RequireSession(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetSessions(w, r)
+		case http.MethodDelete:
+			handlers.RevokeOtherSessions(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	})))
+	mux.HandleFunc("/api/auth/sessions/", middleware.AuthMiddleware(middleware.RequireSession(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.RevokeSession(w, r)
+	})))
+
+	// Personal access tokens for scripts and integrations
+	mux.HandleFunc("/api/auth/tokens", middleware.AuthMiddleware(middleware.RequireSession(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetPersonalAccessTokens(w, r)
+		case http.MethodPost:
+			handlers.CreatePersonalAccessToken(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	})))
+	mux.HandleFunc("/api/auth/tokens/", middleware.AuthMiddleware(middleware.RequireSession(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.RevokePersonalAccessToken(w, r)
+	})))
 }
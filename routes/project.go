@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"just-do-it-api/auth"
+	"just-do-it-api/handlers"
+	"just-do-it-api/middleware"
+	"net/http"
+	"strings"
+)
+
+func RegisterProjectRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/projects", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			middleware.RequireScope(auth.ScopeTasksRead, handlers.GetProjects)(w, r)
+		case http.MethodPost:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.CreateProject)(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	}))))
+
+	mux.HandleFunc("/v1/projects/", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/projects/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/tasks") {
+			requireMethod(http.MethodGet, middleware.RequireScope(auth.ScopeTasksRead, handlers.GetProjectTasks))(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			middleware.RequireScope(auth.ScopeTasksRead, handlers.GetProject)(w, r)
+		case http.MethodPut:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.UpdateProject)(w, r)
+		case http.MethodDelete:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.DeleteProject)(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	}))))
+}
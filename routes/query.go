@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"just-do-it-api/auth"
+	"just-do-it-api/handlers"
+	"just-do-it-api/middleware"
+	"net/http"
+	"strings"
+)
+
+func RegisterQueryRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/queries", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			middleware.RequireScope(auth.ScopeTasksRead, handlers.GetSavedQueries)(w, r)
+		case http.MethodPost:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.CreateSavedQuery)(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	}))))
+
+	mux.HandleFunc("/v1/queries/", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/queries/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/tasks") {
+			requireMethod(http.MethodGet, middleware.RequireScope(auth.ScopeTasksRead, handlers.GetSavedQueryTasks))(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			middleware.RequireScope(auth.ScopeTasksRead, handlers.GetSavedQuery)(w, r)
+		case http.MethodPut:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.UpdateSavedQuery)(w, r)
+		case http.MethodDelete:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.DeleteSavedQuery)(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	}))))
+}
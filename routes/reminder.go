@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"just-do-it-api/auth"
+	"just-do-it-api/handlers"
+	"just-do-it-api/middleware"
+	"net/http"
+	"strings"
+)
+
+func RegisterReminderRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/reminders/", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/reminders/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		requireMethod(http.MethodDelete, middleware.RequireScope(auth.ScopeTasksWrite, handlers.DeleteReminder))(w, r)
+	}))))
+
+	mux.HandleFunc("/v1/notifications", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(
+		requireMethod(http.MethodGet, middleware.RequireScope(auth.ScopeTasksRead, handlers.GetNotifications)),
+	))))
+
+	mux.HandleFunc("/v1/notifications/", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/read") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		requireMethod(http.MethodPost, middleware.RequireScope(auth.ScopeTasksWrite, handlers.MarkNotificationRead))(w, r)
+	}))))
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"just-do-it-api/models"
+)
+
+func methodNotAllowed(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	json.NewEncoder(w).Encode(models.NewErrorResponse(
+		"Method not allowed",
+		"Method not supported for this endpoint",
+	))
+}
+
+// requireMethod answers 405 to requests with any other method
+func requireMethod(method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			methodNotAllowed(w)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"just-do-it-api/auth"
+	"just-do-it-api/handlers"
+	"just-do-it-api/middleware"
+	"net/http"
+	"strings"
+)
+
+func RegisterTagRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/tags", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			middleware.RequireScope(auth.ScopeTasksRead, handlers.GetTags)(w, r)
+		case http.MethodPost:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.CreateTag)(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	}))))
+
+	mux.HandleFunc("/v1/tags/", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/tags/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/merge") {
+			requireMethod(http.MethodPost, middleware.RequireScope(auth.ScopeTasksWrite, handlers.MergeTag))(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			middleware.RequireScope(auth.ScopeTasksRead, handlers.GetTag)(w, r)
+		case http.MethodPut:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.UpdateTag)(w, r)
+		case http.MethodDelete:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.DeleteTag)(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	}))))
+}
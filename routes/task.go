@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"just-do-it-api/auth"
 	"just-do-it-api/handlers"
 	"just-do-it-api/middleware"
 	"just-do-it-api/models"
@@ -12,12 +13,12 @@ import (
 
 func RegisterTaskRoutes(mux *http.ServeMux) {
 	// Base tasks endpoints
-	mux.HandleFunc("/v1/tasks", middleware.Logger(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/v1/tasks", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			handlers.GetTasks(w, r)
+			middleware.RequireScope(auth.ScopeTasksRead, handlers.GetTasks)(w, r)
 		case http.MethodPost:
-			handlers.CreateTask(w, r)
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.CreateTask)(w, r)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			w.Header().Set("Content-Type", "application/json")
@@ -26,10 +27,10 @@ func RegisterTaskRoutes(mux *http.ServeMux) {
 				"Method not supported for this endpoint",
 			))
 		}
-	})))
+	}))))
 
 	// Task operations by ID
-	mux.HandleFunc("/v1/tasks/", middleware.Logger(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/v1/tasks/", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/v1/tasks/" {
 			w.WriteHeader(http.StatusNotFound)
 			return
@@ -38,19 +39,60 @@ func RegisterTaskRoutes(mux *http.ServeMux) {
 		// Handle toggle completion endpoint
 		if strings.HasSuffix(r.URL.Path, "/toggle") {
 			if r.Method == http.MethodPatch {
-				handlers.ToggleTask(w, r)
+				middleware.RequireScope(auth.ScopeTasksWrite, handlers.ToggleTask)(w, r)
 				return
 			}
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
 
+		if strings.HasSuffix(r.URL.Path, "/skip") {
+			requireMethod(http.MethodPost, middleware.RequireScope(auth.ScopeTasksWrite, handlers.SkipOccurrence))(w, r)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/restore") {
+			requireMethod(http.MethodPost, middleware.RequireScope(auth.ScopeTasksWrite, handlers.RestoreTask))(w, r)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/history") {
+			requireMethod(http.MethodGet, middleware.RequireScope(auth.ScopeTasksRead, handlers.GetTaskHistory))(w, r)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/stop-recurring") {
+			requireMethod(http.MethodPost, middleware.RequireScope(auth.ScopeTasksWrite, handlers.StopRecurrence))(w, r)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/reminders") {
+			switch r.Method {
+			case http.MethodGet:
+				middleware.RequireScope(auth.ScopeTasksRead, handlers.GetReminders)(w, r)
+			case http.MethodPost:
+				middleware.RequireScope(auth.ScopeTasksWrite, handlers.CreateReminder)(w, r)
+			default:
+				methodNotAllowed(w)
+			}
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/subtasks") {
+			requireMethod(http.MethodGet, middleware.RequireScope(auth.ScopeTasksRead, handlers.GetSubtasks))(w, r)
+			return
+		}
+
 		// Handle regular CRUD operations
 		switch r.Method {
+		case http.MethodGet:
+			middleware.RequireScope(auth.ScopeTasksRead, handlers.GetTask)(w, r)
 		case http.MethodPut:
-			handlers.UpdateTask(w, r)
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.UpdateTask)(w, r)
+		case http.MethodPatch:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.PatchTask)(w, r)
 		case http.MethodDelete:
-			handlers.DeleteTask(w, r)
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.DeleteTask)(w, r)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			w.Header().Set("Content-Type", "application/json")
@@ -59,9 +101,12 @@ func RegisterTaskRoutes(mux *http.ServeMux) {
 				"Method not supported for this endpoint",
 			))
 		}
-	})))
+	}))))
 
 	// Task filter endpoints
-	mux.HandleFunc("/v1/tasks/today", middleware.Logger(middleware.AuthMiddleware(handlers.GetTodayTasks)))
-	mux.HandleFunc("/v1/tasks/backlog", middleware.Logger(middleware.AuthMiddleware(handlers.GetBacklogTasks)))
+	mux.HandleFunc("/v1/tasks/today", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(middleware.RequireScope(auth.ScopeTasksRead, handlers.GetTodayTasks)))))
+	mux.HandleFunc("/v1/tasks/backlog", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(middleware.RequireScope(auth.ScopeTasksRead, handlers.GetBacklogTasks)))))
+	mux.HandleFunc("/v1/tasks/inbox", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(middleware.RequireScope(auth.ScopeTasksRead, handlers.GetInboxTasks)))))
+	mux.HandleFunc("/v1/tasks/search", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(middleware.RequireScope(auth.ScopeTasksRead, handlers.SearchTasks)))))
+	mux.HandleFunc("/v1/tasks/matrix", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(middleware.RequireScope(auth.ScopeTasksRead, handlers.GetMatrixTasks)))))
 }
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"just-do-it-api/auth"
+	"just-do-it-api/handlers"
+	"just-do-it-api/middleware"
+	"net/http"
+)
+
+func RegisterTrashRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/trash", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			middleware.RequireScope(auth.ScopeTasksRead, handlers.GetTrash)(w, r)
+		case http.MethodDelete:
+			middleware.RequireScope(auth.ScopeTasksWrite, handlers.EmptyTrash)(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+	}))))
+
+	mux.HandleFunc("/v1/trash/", middleware.Logger(middleware.AuthMiddleware(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/trash/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requireMethod(http.MethodDelete, middleware.RequireScope(auth.ScopeTasksWrite, handlers.PurgeTrashedTask))(w, r)
+	}))))
+}
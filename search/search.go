@@ -0,0 +1,223 @@
+// Package search finds the tasks of a user by the words of their title and
+// description. Postgres ranks matches of the search_vector column, SQLite
+// those of its FTS5 index.
+package search
+
+import (
+	"errors"
+	"html"
+	"just-do-it-api/database"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Matches are wrapped in these markers in highlights and snippets
+const (
+	MarkStart = "<mark>"
+	MarkEnd   = "</mark>"
+)
+
+// The engines delimit matches with control characters, which become the
+// markers once the text around them is escaped
+const (
+	matchStart = "\x02"
+	matchEnd   = "\x03"
+)
+
+var markers = strings.NewReplacer(matchStart, MarkStart, matchEnd, MarkEnd)
+
+// MaxTerms bounds the words of a search
+const MaxTerms = 10
+
+// ErrNoTerms is returned for searches without any words
+var ErrNoTerms = errors.New("search for at least one word")
+
+// The ways Tasks searches, by the database it runs on
+const (
+	EnginePostgres = "postgres" // ranked matches of the search_vector column
+	EngineFTS5     = "fts5"     // ranked matches of the SQLite FTS5 index
+	EngineLike     = "like"     // substring matches where SQLite has no FTS5
+)
+
+// Hit is a task that matches a search. Higher ranks are better matches.
+type Hit struct {
+	TaskID  string
+	Rank    float64
+	Title   string // the title, escaped as HTML, with the matches marked
+	Snippet string // the part of the description around the matches, escaped and marked
+}
+
+// Terms splits a search into lowercase words. Everything but letters and
+// digits separates words, so terms can't carry query syntax.
+func Terms(q string) ([]string, error) {
+	terms := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) == 0 {
+		return nil, ErrNoTerms
+	}
+	if len(terms) > MaxTerms {
+		terms = terms[:MaxTerms]
+	}
+	return terms, nil
+}
+
+// Tasks returns the best matches among the tasks of the user. Every term has
+// to match; the last one also matches as the start of a word, so results
+// show up while the user is still typing.
+func Tasks(db *gorm.DB, userID uint, terms []string, limit int) ([]Hit, error) {
+	var hits []Hit
+	var err error
+	switch Engine(db) {
+	case EnginePostgres:
+		err = db.Raw(`
+			SELECT id AS task_id,
+				ts_rank(search_vector, query) AS rank,
+				ts_headline('simple', title, query, ?) AS title,
+				ts_headline('simple', COALESCE(description, ''), query, ?) AS snippet
+			FROM tasks, to_tsquery('simple', ?) AS query
+			WHERE user_id = ? AND deleted_at IS NULL AND search_vector @@ query
+			ORDER BY rank DESC, id
+			LIMIT ?`,
+			`StartSel="`+matchStart+`", StopSel="`+matchEnd+`", HighlightAll=true`,
+			`StartSel="`+matchStart+`", StopSel="`+matchEnd+`", MaxWords=20, MinWords=5`,
+			tsquery(terms), userID, limit,
+		).Scan(&hits).Error
+
+	case EngineFTS5:
+		err = db.Raw(`
+			SELECT tasks.id AS task_id,
+				-bm25(tasks_fts, 10.0, 1.0) AS rank,
+				highlight(tasks_fts, 0, ?, ?) AS title,
+				snippet(tasks_fts, 1, ?, ?, '…', 12) AS snippet
+			FROM tasks_fts JOIN tasks ON tasks.rowid = tasks_fts.rowid
+			WHERE tasks_fts MATCH ? AND tasks.user_id = ? AND tasks.deleted_at IS NULL
+			ORDER BY rank DESC, tasks.id
+			LIMIT ?`,
+			matchStart, matchEnd, matchStart, matchEnd, ftsQuery(terms), userID, limit,
+		).Scan(&hits).Error
+
+	default:
+		hits, err = scan(db, userID, terms, limit)
+	}
+
+	for i := range hits {
+		hits[i].Title = highlight(hits[i].Title)
+		hits[i].Snippet = highlight(hits[i].Snippet)
+	}
+	return hits, err
+}
+
+// highlight escapes text for HTML and turns the delimited matches into marks,
+// so the text of a task can't add markup of its own
+func highlight(text string) string {
+	return markers.Replace(html.EscapeString(text))
+}
+
+// tsquery requires every term, the last one as a prefix
+func tsquery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = "'" + term + "'"
+	}
+	parts[len(parts)-1] += ":*"
+	return strings.Join(parts, " & ")
+}
+
+// ftsQuery is the FTS5 counterpart of tsquery
+func ftsQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = `"` + term + `"`
+	}
+	parts[len(parts)-1] += "*"
+	return strings.Join(parts, " ")
+}
+
+// Engine returns how Tasks searches the database
+func Engine(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return EnginePostgres
+	}
+
+	var count int64
+	db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", database.SearchIndexTable).Scan(&count)
+	if count > 0 {
+		return EngineFTS5
+	}
+	return EngineLike
+}
+
+// scan searches with LIKE where SQLite has no FTS5. Every term matches
+// anywhere in a word, titles rank before descriptions.
+func scan(db *gorm.DB, userID uint, terms []string, limit int) ([]Hit, error) {
+	query := db.Table("tasks").Select("id, title, description").Where("user_id = ? AND deleted_at IS NULL", userID)
+	for _, term := range terms {
+		pattern := "%" + term + "%"
+		query = query.Where("(LOWER(title) LIKE ? OR LOWER(description) LIKE ?)", pattern, pattern)
+	}
+
+	var rows []struct {
+		ID          string
+		Title       string
+		Description string
+	}
+	if err := query.Order("id").Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, len(rows))
+	for i, row := range rows {
+		hits[i] = Hit{TaskID: row.ID, Title: mark(row.Title, terms)}
+		for _, term := range terms {
+			if strings.Contains(strings.ToLower(row.Title), term) {
+				hits[i].Rank++
+			}
+		}
+		if marked := mark(row.Description, terms); marked != row.Description {
+			hits[i].Snippet = marked
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Rank > hits[j].Rank
+	})
+	return hits, nil
+}
+
+// mark delimits the occurrences of the terms in text
+func mark(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Lowercasing changed byte offsets, don't risk splitting characters
+		return text
+	}
+
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		for start := 0; ; {
+			i := strings.Index(lower[start:], term)
+			if i < 0 {
+				break
+			}
+			for j := start + i; j < start+i+len(term); j++ {
+				marked[j] = true
+			}
+			start += i + len(term)
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(matchStart)
+		}
+		b.WriteByte(text[i])
+		if marked[i] && (i == len(text)-1 || !marked[i+1]) {
+			b.WriteString(matchEnd)
+		}
+	}
+	return b.String()
+}